@@ -1,43 +1,134 @@
 
-from sympy import Matrix, symbols, lcm
+from sympy import Matrix, symbols, lcm, gcd
+import json
 import re
 import sys
 
+CHARGE = 'charge'
+
+def parse_charge(species):
+    """Отделяет заряд вида "^2+", "^+2", "^-", "+3" или "++" и возвращает формулу и заряд."""
+    match = re.search(r'\^(\d*)([+-])(\d*)$|([+-])(\d+)$|(\++|-+)$', species)
+    if not match:
+        return species, 0
+    if match.group(2):
+        if match.group(1) and match.group(3):
+            raise ValueError(f'malformed charge in {species}')
+        sign, count = match.group(2), match.group(1) or match.group(3) or '1'
+    elif match.group(4):
+        sign, count = match.group(4), match.group(5)
+    else:
+        sign, count = match.group(6)[0], str(len(match.group(6)))
+    return species[:match.start()], int(count) * (1 if sign == '+' else -1)
+
+def parse_formula(formula):
+    """Разбирает формулу со скобками и гидратной точкой в словарь элементов."""
+    tokens = re.findall(r'[A-Z][a-z]*|\d+|[(\[{]|[)\]}]|[.·*]|\S', formula)
+    stack = [{}]
+    multiplier = 1
+    position = 0
+    while position < len(tokens):
+        token = tokens[position]
+        position += 1
+        count = 1
+        if position < len(tokens) and tokens[position].isdigit():
+            count = int(tokens[position])
+            position += 1
+        if token[0].isupper():
+            stack[-1][token] = stack[-1].get(token, 0) + count * multiplier
+        elif token in '([{':
+            if count != 1:
+                raise ValueError(f'isotopes are not supported by balance.py: {formula}')
+            stack.append({})
+        elif token in ')]}':
+            if len(stack) == 1:
+                raise ValueError(f'unbalanced parentheses in {formula}')
+            group = stack.pop()
+            for element, number in group.items():
+                stack[-1][element] = stack[-1].get(element, 0) + number * count
+        elif token in '.·*':
+            multiplier = count
+        elif token.isdigit() and position == 1:
+            multiplier = int(token)
+        else:
+            raise ValueError(f'unexpected "{token}" in {formula}')
+    if len(stack) != 1:
+        raise ValueError(f'unbalanced parentheses in {formula}')
+    return stack[0]
+
 def parse_compound(compound):
-    """Парсит химическое соединение и возвращает словарь с элементами и их количеством."""
-    elements = re.findall(r'([A-Z][a-z]*)(\d*)', compound)
-    parsed = {}
-    for element, count in elements:
-        parsed[element] = parsed.get(element, 0) + int(count) if count else 1
+    """Парсит химическую частицу и возвращает словарь с элементами, их количеством и зарядом."""
+    compound = compound.strip()
+    if re.fullmatch(r'e\^?-', compound):
+        return {CHARGE: -1}
+    formula, charge = parse_charge(compound)
+    parsed = parse_formula(formula)
+    if charge:
+        parsed[CHARGE] = charge
     return parsed
 
-def build_matrix(equation):
-    """Строит матрицу для балансировки уравнения."""
-    reactants, products = equation.split('=')
-    reactants = reactants.split('+')
-    products = products.split('+')
-
-    compounds = reactants + products
+def build_matrix(reactants, products):
+    """Строит матрицу для балансировки уравнения, со строкой заряда для ионов."""
+    compounds = [parse_compound(compound) for compound in reactants + products]
     all_elements = set()
     for compound in compounds:
-        all_elements.update(parse_compound(compound).keys())
+        all_elements.update(compound.keys())
 
     element_list = sorted(all_elements)
     matrix = []
 
     for element in element_list:
         row = []
-        for compound in reactants:
-            row.append(parse_compound(compound).get(element, 0))
-        for compound in products:
-            row.append(-parse_compound(compound).get(element, 0))
+        for i, compound in enumerate(compounds):
+            sign = 1 if i < len(reactants) else -1
+            row.append(sign * compound.get(element, 0))
         matrix.append(row)
 
     return Matrix(matrix), len(reactants)
 
+def split_equation(equation):
+    """Делит уравнение на реагенты и продукты по "=" и " + ", а без пробелов — по "+"."""
+    reactants, products = equation.split('=')
+    separator = r'\s+\+\s+' if ' + ' in equation else r'\+'
+    return re.split(separator, reactants.strip()), re.split(separator, products.strip())
+
+def integer_vector(vector):
+    """Приводит рациональный вектор к наименьшим целым числам."""
+    lcm_value = lcm([val.q for val in vector])
+    integers = [int(val * lcm_value) for val in vector]
+    divisor = gcd(integers)
+    return [value // divisor for value in integers]
+
+def balance_coefficients(reactants, products):
+    """Возвращает ответ протокола: коэффициенты (сначала реагенты, затем продукты) или базис решений."""
+    matrix, _ = build_matrix(reactants, products)
+    nullspace = matrix.nullspace()
+    if not nullspace:
+        raise ValueError('only the trivial solution conserves every element')
+    if len(nullspace) > 1:
+        return {'basis': [integer_vector(vector) for vector in nullspace]}
+    coefficients = integer_vector(nullspace[0])
+    if coefficients[0] < 0:
+        coefficients = [-c for c in coefficients]
+    if any(c <= 0 for c in coefficients):
+        raise ValueError('equation has no positive solution')
+    return {'coefficients': coefficients}
+
+def serve_json():
+    """Протокол внешнего балансировщика: JSON-запрос в stdin, JSON-ответ в stdout."""
+    request = json.load(sys.stdin)
+    try:
+        if request.get('pins'):
+            raise ValueError('pinned coefficients are not supported by balance.py')
+        response = balance_coefficients(request['reagents'], request['products'])
+    except ValueError as error:
+        response = {'error': str(error)}
+    json.dump(response, sys.stdout)
+
 def balance_chemical_equation(equation):
     """Балансирует химическое уравнение."""
-    matrix, split_index = build_matrix(equation)
+    reactants, products = split_equation(equation)
+    matrix, split_index = build_matrix(reactants, products)
     symbols_list = symbols(f'x0:{matrix.shape[1]}')
 
     # Решаем уравнение
@@ -46,10 +137,6 @@ def balance_chemical_equation(equation):
     coefficients = [int(val * lcm_value) for val in solution]
 
     # Формируем итоговое уравнение
-    reactants, products = equation.split('=')
-    reactants = reactants.split('+')
-    products = products.split('+')
-
     reactant_side = ' + '.join(f'{coefficients[i]} {reactants[i].strip()}' for i in range(split_index))
     product_side = ' + '.join(f'{coefficients[i + split_index]} {products[i].strip()}' for i in range(len(products)))
 
@@ -58,5 +145,8 @@ def balance_chemical_equation(equation):
 # Пример использования
 # requestedData = str(input())
 
-balanced = balance_chemical_equation(sys.argv[1])
-print(balanced)
\ No newline at end of file
+if len(sys.argv) > 1:
+    balanced = balance_chemical_equation(sys.argv[1])
+    print(balanced)
+else:
+    serve_json()
\ No newline at end of file
@@ -0,0 +1,57 @@
+// Command canonicalize fills the canonical_formula column of the compounds
+// table, adding the column first when the database predates it. Every
+// lookup of a compound goes through that column, so run the command after
+// adding or editing compounds:
+//
+//	CONFIG_PATH=config/config.yaml go run ./cmd/canonicalize
+//
+// Formulas that do not parse are logged and get no canonical formula.
+package main
+
+import (
+	"ChemistryPR/internal/config"
+	"ChemistryPR/internal/database"
+	"ChemistryPR/internal/logger"
+	"ChemistryPR/internal/services"
+	"os"
+
+	_ "modernc.org/sqlite"
+)
+
+func main() {
+	config := config.LoadConfig()
+	log := logger.SetupLogger(config.Env)
+
+	db, closeFunc, err := database.OpenDB(config.Driver, config.Dns)
+	if err != nil {
+		log.Error("cannot open database", "error", err)
+		os.Exit(1)
+	}
+	defer closeFunc()
+
+	store := database.NewStore(db)
+	if err := store.MigrateCanonicalFormulas(); err != nil {
+		log.Error("cannot add canonical_formula column", "error", err)
+		os.Exit(1)
+	}
+	formulas, err := store.GetFormulas()
+	if err != nil {
+		log.Error("cannot read formulas", "error", err)
+		os.Exit(1)
+	}
+
+	service := services.ChemicalService{Store: store}
+	skipped := 0
+	for _, formula := range formulas {
+		canonical, err := service.Canonicalize(formula)
+		if err != nil {
+			log.Warn("formula does not parse", "formula", formula, "error", err)
+			skipped++
+		}
+		if err := store.SetCanonicalFormula(formula, canonical); err != nil {
+			log.Error("cannot store canonical formula", "formula", formula, "error", err)
+			os.Exit(1)
+		}
+	}
+	log.Info("canonical formulas updated", "compounds", len(formulas), "skipped", skipped)
+}
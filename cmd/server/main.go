@@ -47,6 +47,26 @@ func main() {
 	e.POST("/molar", handlers.MolarPostHandler)
 	e.GET("/balance", handlers.BalanceGetHandler)
 	e.POST("/balance", handlers.BalancePostHandler)
+	e.GET("/empirical", handlers.EmpiricalGetHandler)
+	e.POST("/empirical", handlers.EmpiricalPostHandler)
+	e.GET("/combustion", handlers.CombustionGetHandler)
+	e.POST("/combustion", handlers.CombustionPostHandler)
+	e.GET("/conversion", handlers.ConversionGetHandler)
+	e.POST("/conversion", handlers.ConversionPostHandler)
+	e.GET("/gas", handlers.GasGetHandler)
+	e.POST("/gas", handlers.GasPostHandler)
+	e.GET("/solution", handlers.SolutionGetHandler)
+	e.POST("/solution", handlers.SolutionPostHandler)
+	e.GET("/ph", handlers.PHGetHandler)
+	e.POST("/ph", handlers.PHPostHandler)
+	e.POST("/api/molar", handlers.MolarAPIHandler)
+	e.POST("/api/balance", handlers.BalanceAPIHandler)
+	e.POST("/api/empirical", handlers.EmpiricalAPIHandler)
+	e.POST("/api/combustion", handlers.CombustionAPIHandler)
+	e.POST("/api/conversion", handlers.ConversionAPIHandler)
+	e.POST("/api/gas", handlers.GasAPIHandler)
+	e.POST("/api/solution", handlers.SolutionAPIHandler)
+	e.POST("/api/ph", handlers.PHAPIHandler)
 	e.GET("/fortune", func(c echo.Context) error {
 		content, err := os.ReadFile("web/fortune.html")
 		if err != nil {
@@ -0,0 +1,17 @@
+-- Replaces the abbreviations spelled as element symbols, which hid
+-- praseodymium, actinium and tennessine (PrCl3 read as C3H7Cl3), with
+-- spellings that include the oxygen the group is bound through: acetoxy
+-- and tosyloxy on either side, as in AcOH, NaOAc and TsOH. Propyl is
+-- written nPr or iPr. The formula parser ignores any abbreviation that is
+-- an element symbol.
+--
+-- Apply after abbreviations.sql with:
+-- sqlite3 database/chem.db < database/abbreviation_spellings.sql
+
+DELETE FROM abbreviations WHERE abbreviation IN ('Pr', 'Ac', 'Ts');
+
+INSERT OR REPLACE INTO abbreviations (abbreviation, formula, name) VALUES
+('AcO', 'C2H3O2', 'acetoxy'),
+('OAc', 'C2H3O2', 'acetoxy'),
+('TsO', 'C7H7SO3', 'tosyloxy'),
+('OTs', 'C7H7SO3', 'tosyloxy');
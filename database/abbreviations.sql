@@ -0,0 +1,41 @@
+-- Functional-group abbreviations expanded by the formula parser. Each
+-- formula is the group as a substituent, e.g. Ph is C6H5 in PhCOOH. Rows
+-- added here are picked up without code changes; an abbreviation spelled
+-- as an element symbol is ignored (see abbreviation_spellings.sql).
+--
+-- Apply with: sqlite3 database/chem.db < database/abbreviations.sql
+
+CREATE TABLE IF NOT EXISTS abbreviations (
+			abbreviation_id INTEGER PRIMARY KEY,
+			abbreviation TEXT UNIQUE,
+			formula TEXT,
+			name TEXT
+		);
+
+INSERT OR REPLACE INTO abbreviations (abbreviation, formula, name) VALUES
+('Me', 'CH3', 'methyl'),
+('Et', 'C2H5', 'ethyl'),
+('Pr', 'C3H7', 'propyl'),
+('nPr', 'C3H7', 'n-propyl'),
+('iPr', 'C3H7', 'isopropyl'),
+('Bu', 'C4H9', 'butyl'),
+('nBu', 'C4H9', 'n-butyl'),
+('iBu', 'C4H9', 'isobutyl'),
+('sBu', 'C4H9', 'sec-butyl'),
+('tBu', 'C4H9', 'tert-butyl'),
+('Cy', 'C6H11', 'cyclohexyl'),
+('Vi', 'C2H3', 'vinyl'),
+('Ph', 'C6H5', 'phenyl'),
+('Bn', 'C7H7', 'benzyl'),
+('Tol', 'C7H7', 'tolyl'),
+('Mes', 'C9H11', 'mesityl'),
+('Tr', 'C19H15', 'trityl'),
+('Ac', 'C2H3O', 'acetyl'),
+('Bz', 'C7H5O', 'benzoyl'),
+('Piv', 'C5H9O', 'pivaloyl'),
+('Ts', 'C7H7SO2', 'tosyl'),
+('Ms', 'CH3SO2', 'mesyl'),
+('Tf', 'CF3SO2', 'triflyl'),
+('Boc', 'C5H9O2', 'tert-butoxycarbonyl'),
+('Cbz', 'C8H7O2', 'benzyloxycarbonyl'),
+('Fmoc', 'C15H11O2', 'fluorenylmethoxycarbonyl');
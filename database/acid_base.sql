@@ -0,0 +1,76 @@
+-- Acid and base dissociation constants in water at 25 °C, from the CRC
+-- Handbook of Chemistry and Physics and Harris, Quantitative Chemical
+-- Analysis. Every row is one proton-transfer step of the compound
+-- canonical_formula, numbered from the neutral compound: an acid gives a
+-- proton from acid to leave base, a base takes one on base to become acid.
+-- ka and pka are of the acid of the step and kb of its base, related by
+-- ka·kb = 1.0e-14. Strong steps go to completion; strong acids keep their
+-- approximate ka and pka, strong hydroxides have none.
+--
+-- Apply with: sqlite3 database/chem.db < database/acid_base.sql
+
+CREATE TABLE IF NOT EXISTS acid_base (
+			acid_base_id INTEGER PRIMARY KEY,
+			formula TEXT,
+			canonical_formula TEXT,
+			name TEXT,
+			kind TEXT,
+			step INTEGER,
+			acid TEXT,
+			base TEXT,
+			strong INTEGER,
+			ka FLOAT,
+			kb FLOAT,
+			pka FLOAT,
+			UNIQUE (canonical_formula, step)
+		);
+
+INSERT OR REPLACE INTO acid_base (formula, canonical_formula, name, kind, step, acid, base, strong, ka, kb, pka) VALUES
+('HCl', 'ClH', 'Хлороводородная кислота', 'acid', 1, 'HCl', 'Cl^-', 1, 2e6, NULL, -6.3),
+('HBr', 'BrH', 'Бромоводородная кислота', 'acid', 1, 'HBr', 'Br^-', 1, 1e9, NULL, -9.0),
+('HI', 'HI', 'Иодоводородная кислота', 'acid', 1, 'HI', 'I^-', 1, 3.16e9, NULL, -9.5),
+('HNO3', 'HNO3', 'Азотная кислота', 'acid', 1, 'HNO3', 'NO3^-', 1, 25.1, NULL, -1.4),
+('HClO4', 'ClHO4', 'Хлорная кислота', 'acid', 1, 'HClO4', 'ClO4^-', 1, 1e10, NULL, -10.0),
+('H2SO4', 'H2O4S', 'Серная кислота', 'acid', 1, 'H2SO4', 'HSO4^-', 1, 1000, NULL, -3.0),
+('H2SO4', 'H2O4S', 'Серная кислота', 'acid', 2, 'HSO4^-', 'SO4^2-', 0, 0.0102, 9.77e-13, 1.99),
+('HF', 'FH', 'Фтороводородная кислота', 'acid', 1, 'HF', 'F^-', 0, 6.76e-4, 1.48e-11, 3.17),
+('HNO2', 'HNO2', 'Азотистая кислота', 'acid', 1, 'HNO2', 'NO2^-', 0, 7.08e-4, 1.41e-11, 3.15),
+('HClO', 'ClHO', 'Хлорноватистая кислота', 'acid', 1, 'HClO', 'ClO^-', 0, 2.95e-8, 3.39e-7, 7.53),
+('HCN', 'CHN', 'Циановодородная кислота', 'acid', 1, 'HCN', 'CN^-', 0, 6.17e-10, 1.62e-5, 9.21),
+('HCOOH', 'CH2O2', 'Муравьиная кислота', 'acid', 1, 'HCOOH', 'HCOO^-', 0, 1.78e-4, 5.62e-11, 3.75),
+('CH3COOH', 'C2H4O2', 'Уксусная кислота', 'acid', 1, 'CH3COOH', 'CH3COO^-', 0, 1.75e-5, 5.70e-10, 4.756),
+('C6H5COOH', 'C7H6O2', 'Бензойная кислота', 'acid', 1, 'C6H5COOH', 'C6H5COO^-', 0, 6.25e-5, 1.60e-10, 4.204),
+('C6H5OH', 'C6H6O', 'Фенол', 'acid', 1, 'C6H5OH', 'C6H5O^-', 0, 1.02e-10, 9.77e-5, 9.99),
+('H3BO3', 'BH3O3', 'Борная кислота', 'acid', 1, 'H3BO3', 'H2BO3^-', 0, 5.75e-10, 1.74e-5, 9.24),
+('H2S', 'H2S', 'Сероводородная кислота', 'acid', 1, 'H2S', 'HS^-', 0, 9.55e-8, 1.05e-7, 7.02),
+('H2S', 'H2S', 'Сероводородная кислота', 'acid', 2, 'HS^-', 'S^2-', 0, 1.26e-14, 0.794, 13.9),
+('H2CO3', 'CH2O3', 'Угольная кислота', 'acid', 1, 'H2CO3', 'HCO3^-', 0, 4.47e-7, 2.24e-8, 6.35),
+('H2CO3', 'CH2O3', 'Угольная кислота', 'acid', 2, 'HCO3^-', 'CO3^2-', 0, 4.68e-11, 2.14e-4, 10.33),
+('H2SO3', 'H2O3S', 'Сернистая кислота', 'acid', 1, 'H2SO3', 'HSO3^-', 0, 0.0141, 7.08e-13, 1.85),
+('H2SO3', 'H2O3S', 'Сернистая кислота', 'acid', 2, 'HSO3^-', 'SO3^2-', 0, 6.31e-8, 1.58e-7, 7.2),
+('H2C2O4', 'C2H2O4', 'Щавелевая кислота', 'acid', 1, 'H2C2O4', 'HC2O4^-', 0, 0.0562, 1.78e-13, 1.25),
+('H2C2O4', 'C2H2O4', 'Щавелевая кислота', 'acid', 2, 'HC2O4^-', 'C2O4^2-', 0, 5.37e-5, 1.86e-10, 4.27),
+('H3PO4', 'H3O4P', 'Фосфорная кислота', 'acid', 1, 'H3PO4', 'H2PO4^-', 0, 0.00708, 1.41e-12, 2.15),
+('H3PO4', 'H3O4P', 'Фосфорная кислота', 'acid', 2, 'H2PO4^-', 'HPO4^2-', 0, 6.31e-8, 1.58e-7, 7.2),
+('H3PO4', 'H3O4P', 'Фосфорная кислота', 'acid', 3, 'HPO4^2-', 'PO4^3-', 0, 4.47e-13, 0.0224, 12.35),
+('H3C6H5O7', 'C6H8O7', 'Лимонная кислота', 'acid', 1, 'H3C6H5O7', 'H2C6H5O7^-', 0, 7.41e-4, 1.35e-11, 3.13),
+('H3C6H5O7', 'C6H8O7', 'Лимонная кислота', 'acid', 2, 'H2C6H5O7^-', 'HC6H5O7^2-', 0, 1.74e-5, 5.75e-10, 4.76),
+('H3C6H5O7', 'C6H8O7', 'Лимонная кислота', 'acid', 3, 'HC6H5O7^2-', 'C6H5O7^3-', 0, 3.98e-7, 2.51e-8, 6.4),
+('NaOH', 'HNaO', 'Гидроксид натрия', 'base', 1, 'Na^+', 'NaOH', 1, NULL, NULL, NULL),
+('KOH', 'HKO', 'Гидроксид калия', 'base', 1, 'K^+', 'KOH', 1, NULL, NULL, NULL),
+('LiOH', 'HLiO', 'Гидроксид лития', 'base', 1, 'Li^+', 'LiOH', 1, NULL, NULL, NULL),
+('Ca(OH)2', 'CaH2O2', 'Гидроксид кальция', 'base', 1, 'CaOH^+', 'Ca(OH)2', 1, NULL, NULL, NULL),
+('Ca(OH)2', 'CaH2O2', 'Гидроксид кальция', 'base', 2, 'Ca^2+', 'CaOH^+', 1, NULL, NULL, NULL),
+('Sr(OH)2', 'H2O2Sr', 'Гидроксид стронция', 'base', 1, 'SrOH^+', 'Sr(OH)2', 1, NULL, NULL, NULL),
+('Sr(OH)2', 'H2O2Sr', 'Гидроксид стронция', 'base', 2, 'Sr^2+', 'SrOH^+', 1, NULL, NULL, NULL),
+('Ba(OH)2', 'BaH2O2', 'Гидроксид бария', 'base', 1, 'BaOH^+', 'Ba(OH)2', 1, NULL, NULL, NULL),
+('Ba(OH)2', 'BaH2O2', 'Гидроксид бария', 'base', 2, 'Ba^2+', 'BaOH^+', 1, NULL, NULL, NULL),
+('NH3', 'H3N', 'Аммиак', 'base', 1, 'NH4^+', 'NH3', 0, 5.62e-10, 1.78e-5, 9.25),
+('CH3NH2', 'CH5N', 'Метиламин', 'base', 1, 'CH3NH3^+', 'CH3NH2', 0, 2.29e-11, 4.37e-4, 10.64),
+('(CH3)2NH', 'C2H7N', 'Диметиламин', 'base', 1, '(CH3)2NH2^+', '(CH3)2NH', 0, 1.86e-11, 5.37e-4, 10.73),
+('(CH3)3N', 'C3H9N', 'Триметиламин', 'base', 1, '(CH3)3NH^+', '(CH3)3N', 0, 1.58e-10, 6.31e-5, 9.8),
+('C5H5N', 'C5H5N', 'Пиридин', 'base', 1, 'C5H5NH^+', 'C5H5N', 0, 5.89e-6, 1.70e-9, 5.23),
+('C6H5NH2', 'C6H7N', 'Анилин', 'base', 1, 'C6H5NH3^+', 'C6H5NH2', 0, 2.40e-5, 4.17e-10, 4.62),
+('N2H4', 'H4N2', 'Гидразин', 'base', 1, 'N2H5^+', 'N2H4', 0, 5.89e-9, 1.70e-6, 8.23),
+('H2NCH2CH2NH2', 'C2H8N2', 'Этилендиамин', 'base', 1, 'H2NCH2CH2NH3^+', 'H2NCH2CH2NH2', 0, 1.17e-10, 8.51e-5, 9.93),
+('H2NCH2CH2NH2', 'C2H8N2', 'Этилендиамин', 'base', 2, 'H3NCH2CH2NH3^2+', 'H2NCH2CH2NH3^+', 0, 1.41e-7, 7.08e-8, 6.85);
@@ -0,0 +1,112 @@
+-- Uncertainties and interval bounds of the standard atomic weights, from
+-- the CIAAW table of standard atomic weights. The uncertainty is the one
+-- given in parentheses after the weight; for the elements whose weight
+-- IUPAC gives as an interval it is the uncertainty of the conventional
+-- value. The interval bounds are set for those elements only. Elements
+-- without a standard atomic weight keep NULL in all three columns.
+--
+-- Apply with: sqlite3 database/chem.db < database/atomic_weights.sql
+
+ALTER TABLE periodic_table ADD COLUMN uncertainty FLOAT;
+ALTER TABLE periodic_table ADD COLUMN interval_min FLOAT;
+ALTER TABLE periodic_table ADD COLUMN interval_max FLOAT;
+
+CREATE TEMPORARY TABLE atomic_weights (
+			symbol TEXT PRIMARY KEY,
+			uncertainty FLOAT,
+			interval_min FLOAT,
+			interval_max FLOAT
+		);
+
+INSERT INTO atomic_weights (symbol, uncertainty, interval_min, interval_max) VALUES
+('H', 0.0002, 1.00784, 1.00811),
+('Li', 0.06, 6.938, 6.997),
+('B', 0.02, 10.806, 10.821),
+('C', 0.002, 12.0096, 12.0116),
+('N', 0.001, 14.00643, 14.00728),
+('O', 0.001, 15.99903, 15.99977),
+('Mg', 0.002, 24.304, 24.307),
+('Si', 0.001, 28.084, 28.086),
+('S', 0.02, 32.059, 32.076),
+('Cl', 0.01, 35.446, 35.457),
+('Ar', 0.16, 39.792, 39.963),
+('Br', 0.003, 79.901, 79.907),
+('Tl', 0.01, 204.382, 204.385),
+('Pb', 1.1, 206.14, 207.94),
+('He', 0.000002, NULL, NULL),
+('Be', 0.0000005, NULL, NULL),
+('F', 0.000000005, NULL, NULL),
+('Ne', 0.0006, NULL, NULL),
+('Na', 0.00000002, NULL, NULL),
+('Al', 0.0000003, NULL, NULL),
+('P', 0.000000005, NULL, NULL),
+('K', 0.0001, NULL, NULL),
+('Ca', 0.004, NULL, NULL),
+('Sc', 0.000004, NULL, NULL),
+('Ti', 0.001, NULL, NULL),
+('V', 0.0001, NULL, NULL),
+('Cr', 0.0006, NULL, NULL),
+('Mn', 0.000002, NULL, NULL),
+('Fe', 0.002, NULL, NULL),
+('Co', 0.000003, NULL, NULL),
+('Ni', 0.0004, NULL, NULL),
+('Cu', 0.003, NULL, NULL),
+('Zn', 0.02, NULL, NULL),
+('Ga', 0.001, NULL, NULL),
+('Ge', 0.008, NULL, NULL),
+('As', 0.000006, NULL, NULL),
+('Se', 0.008, NULL, NULL),
+('Kr', 0.002, NULL, NULL),
+('Rb', 0.0003, NULL, NULL),
+('Sr', 0.01, NULL, NULL),
+('Y', 0.000002, NULL, NULL),
+('Zr', 0.002, NULL, NULL),
+('Nb', 0.00001, NULL, NULL),
+('Mo', 0.01, NULL, NULL),
+('Ru', 0.02, NULL, NULL),
+('Rh', 0.00002, NULL, NULL),
+('Pd', 0.01, NULL, NULL),
+('Ag', 0.0002, NULL, NULL),
+('Cd', 0.004, NULL, NULL),
+('In', 0.001, NULL, NULL),
+('Sn', 0.007, NULL, NULL),
+('Sb', 0.001, NULL, NULL),
+('Te', 0.03, NULL, NULL),
+('I', 0.00003, NULL, NULL),
+('Xe', 0.006, NULL, NULL),
+('Cs', 0.00000006, NULL, NULL),
+('Ba', 0.007, NULL, NULL),
+('La', 0.00007, NULL, NULL),
+('Ce', 0.001, NULL, NULL),
+('Pr', 0.00001, NULL, NULL),
+('Nd', 0.003, NULL, NULL),
+('Sm', 0.02, NULL, NULL),
+('Eu', 0.001, NULL, NULL),
+('Gd', 0.03, NULL, NULL),
+('Tb', 0.000007, NULL, NULL),
+('Dy', 0.001, NULL, NULL),
+('Ho', 0.000005, NULL, NULL),
+('Er', 0.003, NULL, NULL),
+('Tm', 0.000005, NULL, NULL),
+('Yb', 0.010, NULL, NULL),
+('Lu', 0.0001, NULL, NULL),
+('Hf', 0.006, NULL, NULL),
+('Ta', 0.00002, NULL, NULL),
+('W', 0.01, NULL, NULL),
+('Re', 0.001, NULL, NULL),
+('Os', 0.03, NULL, NULL),
+('Ir', 0.002, NULL, NULL),
+('Pt', 0.009, NULL, NULL),
+('Au', 0.000004, NULL, NULL),
+('Hg', 0.003, NULL, NULL),
+('Bi', 0.00001, NULL, NULL),
+('Th', 0.0004, NULL, NULL),
+('Pa', 0.00001, NULL, NULL),
+('U', 0.00003, NULL, NULL);
+
+UPDATE periodic_table SET
+	uncertainty = (SELECT uncertainty FROM atomic_weights WHERE atomic_weights.symbol = TRIM(periodic_table.symbol)),
+	interval_min = (SELECT interval_min FROM atomic_weights WHERE atomic_weights.symbol = TRIM(periodic_table.symbol)),
+	interval_max = (SELECT interval_max FROM atomic_weights WHERE atomic_weights.symbol = TRIM(periodic_table.symbol));
+
+DROP TABLE atomic_weights;
@@ -0,0 +1,40 @@
+-- Densities of liquid reagents in g/mL at 20 °C, from the CRC Handbook of
+-- Chemistry and Physics. The density is that of the pure liquid; solids,
+-- gases and compounds not listed keep NULL. Compounds missing from the
+-- table are added with their canonical formulas, and the density is set on
+-- every record with the canonical formula.
+--
+-- Apply with: sqlite3 database/chem.db < database/densities.sql
+
+ALTER TABLE compounds ADD COLUMN density FLOAT;
+
+CREATE TEMPORARY TABLE densities (
+			canonical_formula TEXT PRIMARY KEY,
+			formula TEXT,
+			name TEXT,
+			appearance TEXT,
+			density FLOAT
+		);
+
+INSERT INTO densities (canonical_formula, formula, name, appearance, density) VALUES
+('H2O', 'H2O', 'Вода', 'Бесцветная жидкость', 0.9982),
+('H2O2', 'H2O2', 'Пероксид водорода', 'Бесцветная жидкость', 1.450),
+('H2O4S', 'H2SO4', 'Серная кислота', 'Бесцветная маслянистая жидкость', 1.8305),
+('HNO3', 'HNO3', 'Азотная кислота', 'Бесцветная дымящая жидкость', 1.513),
+('Br2', 'Br2', 'Бром', 'Красно-бурая жидкость', 3.1028),
+('CH4O', 'CH3OH', 'Метанол', 'Бесцветная жидкость', 0.7914),
+('C2H6O', 'C2H5OH', 'Этанол', 'Бесцветная жидкость', 0.7893),
+('C2H4O2', 'CH3COOH', 'Уксусная кислота', 'Бесцветная жидкость', 1.0492),
+('C3H6O', 'CH3COCH3', 'Ацетон', 'Бесцветная жидкость', 0.7845),
+('C3H8O3', 'C3H5(OH)3', 'Глицерин', 'Бесцветная вязкая жидкость', 1.2613),
+('CHCl3', 'CHCl3', 'Хлороформ', 'Бесцветная жидкость', 1.4788),
+('C6H6', 'C6H6', 'Бензол', 'Бесцветная жидкость', 0.8765);
+
+INSERT INTO compounds (name, formula, appearance, canonical_formula)
+SELECT name, formula, appearance, canonical_formula FROM densities
+WHERE canonical_formula NOT IN (SELECT canonical_formula FROM compounds WHERE canonical_formula IS NOT NULL);
+
+UPDATE compounds SET
+	density = (SELECT density FROM densities WHERE densities.canonical_formula = compounds.canonical_formula);
+
+DROP TABLE densities;
@@ -0,0 +1,220 @@
+-- Isotopes of the elements: mass number, exact atomic mass in u and natural
+-- abundance as a fraction of the element's atoms. Stable nuclides come from
+-- the IUPAC table of isotopic compositions; radionuclides used as labels
+-- (3H, 14C, 18F, 32P, 35S, 45Ca, 60Co, 99Tc, 125I, 131I) have an abundance of 0.
+--
+-- Apply with: sqlite3 database/chem.db < database/isotopes.sql
+
+CREATE TABLE IF NOT EXISTS isotopes (
+			isotope_id INTEGER PRIMARY KEY,
+			symbol TEXT,
+			mass_number INTEGER,
+			exact_mass FLOAT,
+			abundance FLOAT,
+			UNIQUE (symbol, mass_number)
+		);
+
+INSERT OR REPLACE INTO isotopes (symbol, mass_number, exact_mass, abundance) VALUES
+('H', 1, 1.00782503223, 0.999885),
+('H', 2, 2.01410177812, 0.000115),
+('H', 3, 3.01604928132, 0),
+('He', 3, 3.01602932007, 0.00000134),
+('He', 4, 4.00260325413, 0.99999866),
+('Li', 6, 6.0151228874, 0.0759),
+('Li', 7, 7.0160034366, 0.9241),
+('Be', 9, 9.012183065, 1),
+('B', 10, 10.01293695, 0.199),
+('B', 11, 11.00930536, 0.801),
+('C', 12, 12.0000000000, 0.9893),
+('C', 13, 13.00335483507, 0.0107),
+('C', 14, 14.0032419884, 0),
+('N', 14, 14.00307400443, 0.99636),
+('N', 15, 15.00010889888, 0.00364),
+('O', 16, 15.99491461957, 0.99757),
+('O', 17, 16.99913175650, 0.00038),
+('O', 18, 17.99915961286, 0.00205),
+('F', 18, 18.0009380, 0),
+('F', 19, 18.99840316273, 1),
+('Ne', 20, 19.9924401762, 0.9048),
+('Ne', 21, 20.993846685, 0.0027),
+('Ne', 22, 21.991385114, 0.0925),
+('Na', 23, 22.9897692820, 1),
+('Mg', 24, 23.985041697, 0.7899),
+('Mg', 25, 24.985836976, 0.1000),
+('Mg', 26, 25.982592968, 0.1101),
+('Al', 27, 26.98153853, 1),
+('Si', 28, 27.97692653465, 0.92223),
+('Si', 29, 28.97649466490, 0.04685),
+('Si', 30, 29.973770136, 0.03092),
+('P', 31, 30.97376199842, 1),
+('P', 32, 31.97390764, 0),
+('S', 32, 31.9720711744, 0.9499),
+('S', 33, 32.9714589098, 0.0075),
+('S', 34, 33.967867004, 0.0425),
+('S', 35, 34.96903231, 0),
+('S', 36, 35.96708071, 0.0001),
+('Cl', 35, 34.968852682, 0.7576),
+('Cl', 37, 36.965902602, 0.2424),
+('Ar', 36, 35.967545105, 0.003336),
+('Ar', 38, 37.96273211, 0.000629),
+('Ar', 40, 39.9623831237, 0.996035),
+('K', 39, 38.9637064864, 0.932581),
+('K', 40, 39.963998166, 0.000117),
+('K', 41, 40.9618252579, 0.067302),
+('Ca', 40, 39.962590863, 0.96941),
+('Ca', 42, 41.95861783, 0.00647),
+('Ca', 43, 42.95876644, 0.00135),
+('Ca', 44, 43.95548156, 0.02086),
+('Ca', 45, 44.9561863, 0),
+('Ca', 46, 45.9536890, 0.00004),
+('Ca', 48, 47.95252276, 0.00187),
+('Sc', 45, 44.95590828, 1),
+('Ti', 46, 45.95262772, 0.0825),
+('Ti', 47, 46.95175879, 0.0744),
+('Ti', 48, 47.94794198, 0.7372),
+('Ti', 49, 48.94786568, 0.0541),
+('Ti', 50, 49.94478689, 0.0518),
+('V', 50, 49.94715601, 0.0025),
+('V', 51, 50.94395704, 0.9975),
+('Cr', 50, 49.94604183, 0.04345),
+('Cr', 52, 51.94050623, 0.83789),
+('Cr', 53, 52.94064815, 0.09501),
+('Cr', 54, 53.93887916, 0.02365),
+('Mn', 55, 54.93804391, 1),
+('Fe', 54, 53.93960899, 0.05845),
+('Fe', 56, 55.93493633, 0.91754),
+('Fe', 57, 56.93539284, 0.02119),
+('Fe', 58, 57.93327443, 0.00282),
+('Co', 59, 58.93319429, 1),
+('Co', 60, 59.93381630, 0),
+('Ni', 58, 57.93534241, 0.68077),
+('Ni', 60, 59.93078588, 0.26223),
+('Ni', 61, 60.93105557, 0.011399),
+('Ni', 62, 61.92834537, 0.036346),
+('Ni', 64, 63.92796682, 0.009255),
+('Cu', 63, 62.92959772, 0.6915),
+('Cu', 65, 64.92778970, 0.3085),
+('Zn', 64, 63.92914201, 0.4917),
+('Zn', 66, 65.92603381, 0.2773),
+('Zn', 67, 66.92712775, 0.0404),
+('Zn', 68, 67.92484455, 0.1845),
+('Zn', 70, 69.9253192, 0.0061),
+('Ga', 69, 68.9255735, 0.60108),
+('Ga', 71, 70.92470258, 0.39892),
+('Ge', 70, 69.92424875, 0.2057),
+('Ge', 72, 71.922075826, 0.2745),
+('Ge', 73, 72.923458956, 0.0775),
+('Ge', 74, 73.921177761, 0.3650),
+('Ge', 76, 75.921402726, 0.0773),
+('As', 75, 74.92159457, 1),
+('Se', 74, 73.922475934, 0.0089),
+('Se', 76, 75.919213704, 0.0937),
+('Se', 77, 76.919914154, 0.0763),
+('Se', 78, 77.91730928, 0.2377),
+('Se', 80, 79.9165218, 0.4961),
+('Se', 82, 81.9166995, 0.0873),
+('Br', 79, 78.9183376, 0.5069),
+('Br', 81, 80.9162897, 0.4931),
+('Kr', 78, 77.92036494, 0.00355),
+('Kr', 80, 79.91637808, 0.02286),
+('Kr', 82, 81.91348273, 0.11593),
+('Kr', 83, 82.91412716, 0.11500),
+('Kr', 84, 83.9114977282, 0.56987),
+('Kr', 86, 85.9106106269, 0.17279),
+('Rb', 85, 84.9117897379, 0.7217),
+('Rb', 87, 86.9091805310, 0.2783),
+('Sr', 84, 83.9134191, 0.0056),
+('Sr', 86, 85.9092606, 0.0986),
+('Sr', 87, 86.9088775, 0.0700),
+('Sr', 88, 87.9056125, 0.8258),
+('Y', 89, 88.9058403, 1),
+('Zr', 90, 89.9046977, 0.5145),
+('Zr', 91, 90.9056396, 0.1122),
+('Zr', 92, 91.9050347, 0.1715),
+('Zr', 94, 93.9063108, 0.1738),
+('Zr', 96, 95.9082714, 0.0280),
+('Nb', 93, 92.9063730, 1),
+('Mo', 92, 91.90680796, 0.1453),
+('Mo', 94, 93.9050849, 0.0915),
+('Mo', 95, 94.90583877, 0.1584),
+('Mo', 96, 95.90467612, 0.1667),
+('Mo', 97, 96.90601812, 0.0960),
+('Mo', 98, 97.90540482, 0.2439),
+('Mo', 100, 99.9074718, 0.0982),
+('Tc', 99, 98.9062508, 0),
+('Rh', 103, 102.905498, 1),
+('Pd', 102, 101.9056022, 0.0102),
+('Pd', 104, 103.9040305, 0.1114),
+('Pd', 105, 104.9050796, 0.2233),
+('Pd', 106, 105.9034804, 0.2733),
+('Pd', 108, 107.9038916, 0.2646),
+('Pd', 110, 109.9051722, 0.1172),
+('Ag', 107, 106.9050916, 0.51839),
+('Ag', 109, 108.9047553, 0.48161),
+('Cd', 106, 105.9064599, 0.0125),
+('Cd', 108, 107.9041834, 0.0089),
+('Cd', 110, 109.90300661, 0.1249),
+('Cd', 111, 110.90418287, 0.1280),
+('Cd', 112, 111.90276287, 0.2413),
+('Cd', 113, 112.90440813, 0.1222),
+('Cd', 114, 113.90336509, 0.2873),
+('Cd', 116, 115.90476315, 0.0749),
+('Sn', 112, 111.90482387, 0.0097),
+('Sn', 114, 113.9027827, 0.0066),
+('Sn', 115, 114.903344699, 0.0034),
+('Sn', 116, 115.90174280, 0.1454),
+('Sn', 117, 116.90295398, 0.0768),
+('Sn', 118, 117.90160657, 0.2422),
+('Sn', 119, 118.90331117, 0.0859),
+('Sn', 120, 119.90220163, 0.3258),
+('Sn', 122, 121.9034438, 0.0463),
+('Sn', 124, 123.9052766, 0.0579),
+('Sb', 121, 120.903812, 0.5721),
+('Sb', 123, 122.9042132, 0.4279),
+('Te', 120, 119.9040593, 0.0009),
+('Te', 122, 121.9030435, 0.0255),
+('Te', 123, 122.9042698, 0.0089),
+('Te', 124, 123.9028171, 0.0474),
+('Te', 125, 124.9044299, 0.0707),
+('Te', 126, 125.9033109, 0.1884),
+('Te', 128, 127.90446128, 0.3174),
+('Te', 130, 129.906222748, 0.3408),
+('I', 125, 124.9046294, 0),
+('I', 127, 126.9044719, 1),
+('I', 131, 130.9061263, 0),
+('Cs', 133, 132.905451961, 1),
+('Ba', 130, 129.9063207, 0.00106),
+('Ba', 132, 131.9050611, 0.00101),
+('Ba', 134, 133.90450818, 0.02417),
+('Ba', 135, 134.90568838, 0.06592),
+('Ba', 136, 135.90457573, 0.07854),
+('Ba', 137, 136.90582714, 0.11232),
+('Ba', 138, 137.90524700, 0.71698),
+('W', 180, 179.9467108, 0.0012),
+('W', 182, 181.94820394, 0.2650),
+('W', 183, 182.95022275, 0.1431),
+('W', 184, 183.95093092, 0.3064),
+('W', 186, 185.9543628, 0.2843),
+('Pt', 190, 189.9599297, 0.00012),
+('Pt', 192, 191.9610387, 0.00782),
+('Pt', 194, 193.9626809, 0.3286),
+('Pt', 195, 194.9647917, 0.3378),
+('Pt', 196, 195.96495209, 0.2521),
+('Pt', 198, 197.9678949, 0.07356),
+('Au', 197, 196.96656879, 1),
+('Hg', 196, 195.9658326, 0.0015),
+('Hg', 198, 197.96676860, 0.0997),
+('Hg', 199, 198.96828064, 0.1687),
+('Hg', 200, 199.96832659, 0.2310),
+('Hg', 201, 200.97030284, 0.1318),
+('Hg', 202, 201.97064340, 0.2986),
+('Hg', 204, 203.97349398, 0.0687),
+('Pb', 204, 203.9730440, 0.014),
+('Pb', 206, 205.9744657, 0.241),
+('Pb', 207, 206.9758973, 0.221),
+('Pb', 208, 207.9766525, 0.524),
+('Bi', 209, 208.9803991, 1),
+('Th', 232, 232.0380558, 1),
+('U', 234, 234.0409523, 0.000054),
+('U', 235, 235.0439301, 0.007204),
+('U', 238, 238.0507884, 0.992742);
@@ -0,0 +1,108 @@
+-- Standard atomic weights from the CIAAW table, replacing the stored ones
+-- so that each goes with the uncertainty and interval set by
+-- atomic_weights.sql; for the elements whose weight IUPAC gives as an
+-- interval it is the conventional value. Elements without a standard
+-- atomic weight keep their stored weight. The header row of the original
+-- import is deleted.
+--
+-- Apply after atomic_weights.sql with:
+-- sqlite3 database/chem.db < database/standard_weights.sql
+
+CREATE TEMPORARY TABLE standard_weights (
+			symbol TEXT PRIMARY KEY,
+			atomic_weight FLOAT
+		);
+
+INSERT INTO standard_weights (symbol, atomic_weight) VALUES
+('H', 1.0080),
+('Li', 6.94),
+('B', 10.81),
+('C', 12.011),
+('N', 14.007),
+('O', 15.999),
+('Mg', 24.305),
+('Si', 28.085),
+('S', 32.06),
+('Cl', 35.45),
+('Ar', 39.95),
+('Br', 79.904),
+('Tl', 204.38),
+('Pb', 207.2),
+('He', 4.002602),
+('Be', 9.0121831),
+('F', 18.998403162),
+('Ne', 20.1797),
+('Na', 22.98976928),
+('Al', 26.9815384),
+('P', 30.973761998),
+('K', 39.0983),
+('Ca', 40.078),
+('Sc', 44.955907),
+('Ti', 47.867),
+('V', 50.9415),
+('Cr', 51.9961),
+('Mn', 54.938043),
+('Fe', 55.845),
+('Co', 58.933194),
+('Ni', 58.6934),
+('Cu', 63.546),
+('Zn', 65.38),
+('Ga', 69.723),
+('Ge', 72.630),
+('As', 74.921595),
+('Se', 78.971),
+('Kr', 83.798),
+('Rb', 85.4678),
+('Sr', 87.62),
+('Y', 88.905838),
+('Zr', 91.224),
+('Nb', 92.90637),
+('Mo', 95.95),
+('Ru', 101.07),
+('Rh', 102.90549),
+('Pd', 106.42),
+('Ag', 107.8682),
+('Cd', 112.414),
+('In', 114.818),
+('Sn', 118.710),
+('Sb', 121.760),
+('Te', 127.60),
+('I', 126.90447),
+('Xe', 131.293),
+('Cs', 132.90545196),
+('Ba', 137.327),
+('La', 138.90547),
+('Ce', 140.116),
+('Pr', 140.90766),
+('Nd', 144.242),
+('Sm', 150.36),
+('Eu', 151.964),
+('Gd', 157.25),
+('Tb', 158.925354),
+('Dy', 162.500),
+('Ho', 164.930329),
+('Er', 167.259),
+('Tm', 168.934219),
+('Yb', 173.045),
+('Lu', 174.9668),
+('Hf', 178.486),
+('Ta', 180.94788),
+('W', 183.84),
+('Re', 186.207),
+('Os', 190.23),
+('Ir', 192.217),
+('Pt', 195.084),
+('Au', 196.966570),
+('Hg', 200.592),
+('Bi', 208.98040),
+('Th', 232.0377),
+('Pa', 231.03588),
+('U', 238.02891);
+
+UPDATE periodic_table SET
+	atomic_weight = (SELECT atomic_weight FROM standard_weights WHERE standard_weights.symbol = TRIM(periodic_table.symbol))
+WHERE TRIM(symbol) IN (SELECT symbol FROM standard_weights);
+
+DROP TABLE standard_weights;
+
+DELETE FROM periodic_table WHERE symbol = 'Symbol' AND atomic_weight = 'AtomicMass';
@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
@@ -14,6 +16,7 @@ type Config struct {
 	Dns        string `yaml:"dns"`
 	Root       string `yaml:"root"`
 	HTTPServer `yaml:"http_server"`
+	Balancer   Balancer `yaml:"balancer"`
 }
 
 type HTTPServer struct {
@@ -23,6 +26,21 @@ type HTTPServer struct {
 	IdleTimeout time.Duration `yaml:"idle-timeout" env-default:"60s"`
 }
 
+// Balancer selects the backend used to balance chemical equations.
+//
+// Backend is one of "native" (pure Go), "python" (Interpreter running Script)
+// or "external" (Command with Args). Relative Script and Command paths are
+// resolved against the directory of the configuration file, so the server
+// does not depend on the directory it was started from.
+type Balancer struct {
+	Backend     string        `yaml:"backend" env-default:"native"`
+	Interpreter string        `yaml:"interpreter" env-default:"python3"`
+	Script      string        `yaml:"script" env-default:"../balance.py"`
+	Command     string        `yaml:"command"`
+	Args        []string      `yaml:"args"`
+	Timeout     time.Duration `yaml:"timeout" env-default:"10s"`
+}
+
 func LoadConfig() Config {
 	var config Config
 	config_path := os.Getenv("CONFIG_PATH")
@@ -35,5 +53,18 @@ func LoadConfig() Config {
 	if err := cleanenv.ReadConfig(config_path, &config); err != nil {
 		log.Fatal("parsing error")
 	}
+	config.Balancer.resolvePaths(filepath.Dir(config_path))
 	return config
 }
+
+// resolvePaths makes the script and command paths absolute relative to dir.
+// A command without a path separator is left alone so that it is looked up
+// in PATH.
+func (balancer *Balancer) resolvePaths(dir string) {
+	if balancer.Script != "" && !filepath.IsAbs(balancer.Script) {
+		balancer.Script = filepath.Join(dir, balancer.Script)
+	}
+	if strings.ContainsRune(balancer.Command, filepath.Separator) && !filepath.IsAbs(balancer.Command) {
+		balancer.Command = filepath.Join(dir, balancer.Command)
+	}
+}
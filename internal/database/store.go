@@ -4,6 +4,8 @@ import (
 	"ChemistryPR/internal/models"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 )
 
 // openDB opens a database connection using the provided driver and data source name (DSN).
@@ -61,10 +63,16 @@ func NewStore(db *sql.DB) Store {
 	return Store{DB: db}
 }
 
+// ErrElementNotFound is returned by GetElement and GetElements for a symbol
+// that is not in the periodic table.
+var ErrElementNotFound = errors.New("element not found")
+
 // GetElement retrieves an element from the periodic table by its symbol.
 //
 // It queries the database for the element with the specified symbol,
-// scanning the result into a models.Element struct.
+// scanning the result into a models.Element struct. Whitespace stored
+// around a symbol in the table is ignored. A missing uncertainty or interval
+// is read as zero.
 //
 // Parameters:
 //
@@ -75,14 +83,17 @@ func NewStore(db *sql.DB) Store {
 //	models.Element: The element corresponding to the provided symbol.
 //	error: An error, if any occurred during the database query.
 //	       If no element is found, the returned element will be empty
-//	       and the error will be nil.
+//	       and the error will wrap ErrElementNotFound.
 func (store Store) GetElement(symbol string) (models.Element, error) {
-	row := store.DB.QueryRow("SELECT name, symbol, atomic_weight FROM periodic_table WHERE symbol = ?", symbol)
+	row := store.DB.QueryRow(`SELECT name, TRIM(symbol), atomic_weight,
+		COALESCE(uncertainty, 0), COALESCE(interval_min, 0), COALESCE(interval_max, 0)
+		FROM periodic_table WHERE TRIM(symbol) = ?`, symbol)
 
 	gottenElement := models.Element{}
-	err := row.Scan(&gottenElement.Name, &gottenElement.Symbol, &gottenElement.AtomicWeight)
+	err := row.Scan(&gottenElement.Name, &gottenElement.Symbol, &gottenElement.AtomicWeight,
+		&gottenElement.Uncertainty, &gottenElement.IntervalMin, &gottenElement.IntervalMax)
 	if errors.Is(err, sql.ErrNoRows) {
-		return models.Element{}, nil
+		return models.Element{}, fmt.Errorf("%w: %s", ErrElementNotFound, symbol)
 	}
 	if err != nil {
 		return models.Element{}, err
@@ -91,6 +102,146 @@ func (store Store) GetElement(symbol string) (models.Element, error) {
 	return gottenElement, nil
 }
 
+// GetSymbols retrieves the symbols of all elements in the periodic table,
+// ordered by atomic number.
+//
+// Returns:
+//   - A slice with one symbol per element, without surrounding whitespace.
+//   - An error, if any occurred during the database query.
+func (store Store) GetSymbols() ([]string, error) {
+	rows, err := store.DB.Query("SELECT TRIM(symbol) FROM periodic_table WHERE LENGTH(TRIM(symbol)) <= 3 ORDER BY element_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var symbols []string
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, rows.Err()
+}
+
+// ErrIsotopeNotFound is returned by GetIsotope for a nuclide that is not in
+// the isotopes table.
+var ErrIsotopeNotFound = errors.New("isotope not found")
+
+// GetIsotope retrieves a nuclide from the isotopes table.
+//
+// Parameters:
+//
+//	nuclide (models.Nuclide): The element symbol and mass number of the isotope.
+//
+// Returns:
+//
+//	models.Isotope: The isotope with its exact mass and natural abundance.
+//	error: An error, if any occurred during the database query.
+//	       If the nuclide is not in the table, the error wraps
+//	       ErrIsotopeNotFound.
+func (store Store) GetIsotope(nuclide models.Nuclide) (models.Isotope, error) {
+	row := store.DB.QueryRow("SELECT exact_mass, abundance FROM isotopes WHERE symbol = ? AND mass_number = ?",
+		nuclide.Symbol, nuclide.MassNumber)
+
+	gottenIsotope := models.Isotope{Nuclide: nuclide}
+	err := row.Scan(&gottenIsotope.ExactMass, &gottenIsotope.Abundance)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Isotope{}, fmt.Errorf("%w: %s", ErrIsotopeNotFound, nuclide)
+	}
+	if err != nil {
+		return models.Isotope{}, err
+	}
+
+	return gottenIsotope, nil
+}
+
+// GetIsotopes retrieves every isotope of an element listed in the isotopes
+// table, ordered by mass number. Radionuclides are included with an
+// abundance of zero.
+//
+// Parameters:
+//
+//	symbol (string): The chemical symbol of the element.
+//
+// Returns:
+//
+//	[]models.Isotope: The isotopes of the element, empty if none are listed.
+//	error: An error, if any occurred during the database query.
+func (store Store) GetIsotopes(symbol string) ([]models.Isotope, error) {
+	rows, err := store.DB.Query("SELECT mass_number, exact_mass, abundance FROM isotopes WHERE symbol = ? ORDER BY mass_number", symbol)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	gottenIsotopes := make([]models.Isotope, 0)
+	for rows.Next() {
+		isotope := models.Isotope{Nuclide: models.Nuclide{Symbol: symbol}}
+		if err := rows.Scan(&isotope.MassNumber, &isotope.ExactMass, &isotope.Abundance); err != nil {
+			return nil, err
+		}
+		gottenIsotopes = append(gottenIsotopes, isotope)
+	}
+	return gottenIsotopes, rows.Err()
+}
+
+// GetAbbreviations retrieves the functional-group abbreviations known to the
+// formula parser. Abbreviations spelled as an element symbol are left out so
+// that they cannot hide the element.
+//
+// Returns:
+//   - A map from every abbreviation, e.g. "Ph", to the formula it stands
+//     for, e.g. "C6H5".
+//   - An error, if any occurred during the database query.
+func (store Store) GetAbbreviations() (map[string]string, error) {
+	rows, err := store.DB.Query("SELECT abbreviation, formula FROM abbreviations WHERE TRIM(abbreviation) NOT IN (SELECT TRIM(symbol) FROM periodic_table)")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	abbreviations := make(map[string]string)
+	for rows.Next() {
+		var abbreviation, formula string
+		if err := rows.Scan(&abbreviation, &formula); err != nil {
+			return nil, err
+		}
+		abbreviations[strings.TrimSpace(abbreviation)] = strings.TrimSpace(formula)
+	}
+	return abbreviations, rows.Err()
+}
+
+// GetAcidBase retrieves the proton-transfer steps of an acid or a base from
+// the acid_base table by the canonical formula of the neutral compound,
+// ordered by step. Missing constants are read as zero.
+//
+// Returns:
+//   - The steps of the compound, empty if it is not in the table.
+//   - An error, if any occurred during the database query.
+func (store Store) GetAcidBase(canonical string) ([]models.AcidBaseStep, error) {
+	rows, err := store.DB.Query(`SELECT formula, name, kind, step, acid, base, strong,
+		COALESCE(ka, 0), COALESCE(kb, 0), COALESCE(pka, 0)
+		FROM acid_base WHERE canonical_formula = ? ORDER BY step`, canonical)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	steps := make([]models.AcidBaseStep, 0)
+	for rows.Next() {
+		var step models.AcidBaseStep
+		if err := rows.Scan(&step.Formula, &step.Name, &step.Kind, &step.Step, &step.Acid, &step.Base, &step.Strong,
+			&step.Ka, &step.Kb, &step.PKa); err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, rows.Err()
+}
+
 // GetElements retrieves the elements that make up a given compound.
 //
 // It takes a 'target' of type models.Compound, which contains the
@@ -114,34 +265,36 @@ func (store Store) GetElements(target models.Compound) ([]models.Element, error)
 	return gottenElements, nil
 }
 
-// GetCompound retrieves a chemical compound from the database using its formula.
-// It queries the database for a compound based on the provided formula and returns
-// the corresponding compound's details including its formula, name, and appearance.
+// GetCompound retrieves a chemical compound from the database using its canonical formula.
+// It queries the database for a compound whose canonical_formula column matches the provided
+// formula and returns the corresponding compound's details including its formula as stored,
+// name, appearance and density. A missing density is read as zero.
 //
 // Arguments:
 //
-//	formula (string): The chemical formula of the compound to retrieve.
+//	canonical (string): The canonical formula of the compound to retrieve, as written by
+//	services.CanonicalFormula, so that "OH2" and "H2O" find the same record.
 //
 // Returns:
 //
-//	models.Compound: The compound with the requested formula, including its name and appearance.
+//	models.Compound: The compound with the requested formula, including its name, appearance and density.
 //	error: An error, if any, that occurred while querying the database or scanning the results.
 //
 // If no compound is found with the given formula, the function returns an empty compound and nil error.
 //
 // Example:
 //
-//	formula := "H2O"
-//	compound, err := store.GetCompound(formula)
+//	canonical := "H2O"
+//	compound, err := store.GetCompound(canonical)
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
 //	fmt.Println(compound.Name, compound.Appearance)
-func (store Store) GetCompound(formula string) (models.Compound, error) {
-	row := store.DB.QueryRow("SELECT formula, name, appearance FROM compounds WHERE formula = ?", formula)
+func (store Store) GetCompound(canonical string) (models.Compound, error) {
+	row := store.DB.QueryRow("SELECT formula, name, appearance, COALESCE(density, 0) FROM compounds WHERE canonical_formula = ?", canonical)
 
 	gottenCompound := models.Compound{}
-	err := row.Scan(&gottenCompound.Formula, &gottenCompound.Name, &gottenCompound.Appearance)
+	err := row.Scan(&gottenCompound.Formula, &gottenCompound.Name, &gottenCompound.Appearance, &gottenCompound.Density)
 	if errors.Is(err, sql.ErrNoRows) {
 		return models.Compound{}, nil
 	}
@@ -152,12 +305,12 @@ func (store Store) GetCompound(formula string) (models.Compound, error) {
 	return gottenCompound, nil
 }
 
-// GetCompounds retrieves multiple compounds from the database based on a list of formulas.
+// GetCompounds retrieves multiple compounds from the database based on a list of canonical formulas.
 // It calls GetCompound for each formula in the provided list and collects the results.
 //
 // Arguments:
 //
-//	formulas ([]string): A slice of chemical formulas for which the compounds are to be retrieved.
+//	formulas ([]string): A slice of canonical formulas for which the compounds are to be retrieved.
 //
 // Returns:
 //
@@ -185,3 +338,48 @@ func (store Store) GetCompounds(formulas []string) ([]models.Compound, error) {
 	}
 	return gottenCompound, nil
 }
+
+// MigrateCanonicalFormulas adds the canonical_formula column and its index
+// to the compounds table unless they already exist.
+func (store Store) MigrateCanonicalFormulas() error {
+	var count int
+	err := store.DB.QueryRow("SELECT COUNT(*) FROM pragma_table_info('compounds') WHERE name = 'canonical_formula'").Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		if _, err := store.DB.Exec("ALTER TABLE compounds ADD COLUMN canonical_formula TEXT"); err != nil {
+			return err
+		}
+	}
+	_, err = store.DB.Exec("CREATE INDEX IF NOT EXISTS compounds_canonical_formula ON compounds (canonical_formula)")
+	return err
+}
+
+// GetFormulas retrieves the distinct formulas of the compounds table as
+// they are stored.
+func (store Store) GetFormulas() ([]string, error) {
+	rows, err := store.DB.Query("SELECT DISTINCT formula FROM compounds ORDER BY compound_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var formulas []string
+	for rows.Next() {
+		var formula string
+		if err := rows.Scan(&formula); err != nil {
+			return nil, err
+		}
+		formulas = append(formulas, formula)
+	}
+	return formulas, rows.Err()
+}
+
+// SetCanonicalFormula stores canonical as the canonical formula of every
+// compound whose formula is stored as formula. An empty canonical formula
+// is stored as NULL, so the compound is never found by GetCompound.
+func (store Store) SetCanonicalFormula(formula, canonical string) error {
+	_, err := store.DB.Exec("UPDATE compounds SET canonical_formula = NULLIF(?, '') WHERE formula = ?", canonical, formula)
+	return err
+}
@@ -8,7 +8,6 @@ import (
 	"os"
 
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/gommon/log"
 )
 
 // type SubstanceDiscription struct {
@@ -23,6 +22,18 @@ type BalanceResponse struct {
 	Products []string //SubstanceDiscription
 }
 
+// balancePage is the data of the "balance" template: the entered amount
+// and the result or the error of the last request.
+type balancePage struct {
+	services.BalanceResponse
+	Given     string            // Species whose amount was entered
+	Amount    string            // Amount as entered, e.g. "36 g"
+	Available string            // Available amounts of the reagents as entered
+	Actual    string            // Actual yields of the products as entered
+	Gas       map[string]string // Gas conditions as entered
+	Error     *ErrorResponse
+}
+
 func BalanceGetHandler(c echo.Context) error {
 	config := config.LoadConfig()
 	content, err := os.ReadFile(config.Root + "/balance.html")
@@ -32,20 +43,78 @@ func BalanceGetHandler(c echo.Context) error {
 	return c.HTMLBlob(200, content)
 }
 
-func BalancePostHandler(c echo.Context) error {
+// balanceResponse balances the "reaction" form value with the options from
+// the "mode", "medium", "pins" and "explain" form values, and computes the
+// stoichiometry for the "amount" of the "given" species and the yield for
+// the "available" amounts of the reagents and the "actual" yields, with gas
+// volumes at the "conditions", "temperature" and "pressure".
+func balanceResponse(c echo.Context) (services.BalanceResponse, error) {
 	config := config.LoadConfig()
-	db, closeFunc, err := database.OpenDB(config.Driver, config.Dns)
+	reaction := c.FormValue("reaction")
+	db, closeFunc, err := openDatabase(config)
 	if err != nil {
-		log.Debug("pizda bd nakrilas")
-		panic("pizda bd nakrilas")
+		return services.BalanceResponse{Reaction: reaction}, err
 	}
 	defer closeFunc()
-	reaction := c.FormValue("reaction")
 	service := services.BalanceService{}
 	service.Store = database.NewStore(db)
-	response, err := service.GetResponse(reaction)
-	for err != nil {
-		c.String(http.StatusBadRequest, "pizda huinu napisal")
+	service.Balancer, err = services.NewBalancer(config.Balancer, service.ChemicalService)
+	if err != nil {
+		return services.BalanceResponse{Reaction: reaction}, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	amount, err := formQuantity(c, "amount")
+	if err != nil {
+		return services.BalanceResponse{Reaction: reaction}, err
+	}
+	precision, err := formPrecision(c)
+	if err != nil {
+		return services.BalanceResponse{Reaction: reaction}, err
+	}
+	gas, err := formGasConditions(c)
+	if err != nil {
+		return services.BalanceResponse{Reaction: reaction}, err
+	}
+	options := services.BalanceOptions{
+		Mode:      services.BalanceMode(c.FormValue("mode")),
+		Medium:    services.Medium(c.FormValue("medium")),
+		Pins:      c.FormValue("pins"),
+		Explain:   c.FormValue("explain") != "",
+		Given:     c.FormValue("given"),
+		Amount:    amount,
+		Available: c.FormValue("available"),
+		Actual:    c.FormValue("actual"),
+		Gas:       gas,
+		Precision: precision,
+	}
+	return service.GetResponse(reaction, options)
+}
+
+func BalancePostHandler(c echo.Context) error {
+	response, err := balanceResponse(c)
+	page := balancePage{
+		BalanceResponse: response,
+		Given:           c.FormValue("given"),
+		Amount:          c.FormValue("amount"),
+		Available:       c.FormValue("available"),
+		Actual:          c.FormValue("actual"),
+		Gas:             make(map[string]string),
+	}
+	for _, field := range []string{"conditions", "temperature", "pressure"} {
+		page.Gas[field] = c.FormValue(field)
+	}
+	if err != nil {
+		page.Error = NewErrorResponse(err)
+		return c.Render(errorStatus(err), "balance", page)
+	}
+	return c.Render(http.StatusOK, "balance", page)
+}
+
+// BalanceAPIHandler balances the "reaction" form or query value and returns
+// the BalanceResponse as JSON, or an ErrorResponse on failure.
+func BalanceAPIHandler(c echo.Context) error {
+	response, err := balanceResponse(c)
+	if err != nil {
+		return c.JSON(errorStatus(err), NewErrorResponse(err))
 	}
-	return c.Render(http.StatusOK, "balance", response)
+	return c.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"ChemistryPR/internal/config"
+	"ChemistryPR/internal/database"
+	"ChemistryPR/internal/services"
+	"ChemistryPR/internal/units"
+	"net/http"
+	"os"
+
+	"github.com/labstack/echo/v4"
+)
+
+// combustionPage is the data of the "combustion" template: the entered
+// masses and the result or the error of the last request.
+type combustionPage struct {
+	services.CombustionResponse
+	Input     map[string]string
+	MolarMass string
+	Error     *ErrorResponse
+}
+
+// combustionFields are the form values of a combustion analysis, in grams.
+var combustionFields = []string{"sample", "co2", "h2o", "n2", "so2"}
+
+func CombustionGetHandler(c echo.Context) error {
+	config := config.LoadConfig()
+	content, err := os.ReadFile(config.Root + "/combustion.html")
+	if err != nil {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	return c.HTMLBlob(200, content)
+}
+
+// combustionResponse analyses the masses in the "sample", "co2", "h2o",
+// "n2" and "so2" form values and, when the "molar_mass" form value is set,
+// finds the molecular formula too, rounded as the "precision" or "sigfigs"
+// form value asks.
+func combustionResponse(c echo.Context) (services.CombustionResponse, error) {
+	config := config.LoadConfig()
+	db, closeFunc, err := openDatabase(config)
+	if err != nil {
+		return services.CombustionResponse{}, err
+	}
+	defer closeFunc()
+	masses := make([]units.Quantity, len(combustionFields))
+	for i, field := range combustionFields {
+		if masses[i], err = formQuantity(c, field); err != nil {
+			return services.CombustionResponse{}, err
+		}
+	}
+	options, err := empiricalOptions(c)
+	if err != nil {
+		return services.CombustionResponse{}, err
+	}
+	input := services.CombustionInput{SampleMass: masses[0], CO2: masses[1], H2O: masses[2], N2: masses[3], SO2: masses[4]}
+	s := services.CombustionService{}
+	s.Store = database.NewStore(db)
+	return s.GetResponse(input, options)
+}
+
+func CombustionPostHandler(c echo.Context) error {
+	response, err := combustionResponse(c)
+	page := combustionPage{CombustionResponse: response, Input: make(map[string]string), MolarMass: c.FormValue("molar_mass")}
+	for _, field := range combustionFields {
+		page.Input[field] = c.FormValue(field)
+	}
+	if err != nil {
+		page.Error = NewErrorResponse(err)
+		return c.Render(errorStatus(err), "combustion", page)
+	}
+	return c.Render(http.StatusOK, "combustion", page)
+}
+
+// CombustionAPIHandler returns the composition and the formulas found
+// from the combustion masses in the form or query values as JSON, or an
+// ErrorResponse on failure.
+func CombustionAPIHandler(c echo.Context) error {
+	response, err := combustionResponse(c)
+	if err != nil {
+		return c.JSON(errorStatus(err), NewErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, response)
+}
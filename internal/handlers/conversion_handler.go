@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"ChemistryPR/internal/config"
+	"ChemistryPR/internal/database"
+	"ChemistryPR/internal/services"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// conversionPage is the data of the "conversion" template: the entered
+// quantity and the result or the error of the last request.
+type conversionPage struct {
+	services.ConversionResponse
+	Quantity string
+	Unit     string
+	Element  string
+	Error    *ErrorResponse
+}
+
+func ConversionGetHandler(c echo.Context) error {
+	config := config.LoadConfig()
+	content, err := os.ReadFile(config.Root + "/conversion.html")
+	if err != nil {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	return c.HTMLBlob(200, content)
+}
+
+// conversionResponse converts the "quantity" form value, in the unit of
+// the "unit" form value, of the compound in the "formula" or "smiles" form
+// value. Atoms are counted for the element of the "element" form value.
+func conversionResponse(c echo.Context) (services.ConversionResponse, error) {
+	config := config.LoadConfig()
+	formula := c.FormValue("formula")
+	db, closeFunc, err := openDatabase(config)
+	if err != nil {
+		return services.ConversionResponse{Formula: formula}, err
+	}
+	defer closeFunc()
+	if smiles := strings.TrimSpace(c.FormValue("smiles")); smiles != "" {
+		formula = services.SMILESPrefix + smiles
+	}
+	quantity, err := formQuantity(c, "quantity")
+	if err != nil {
+		return services.ConversionResponse{Formula: formula}, err
+	}
+	precision, err := formPrecision(c)
+	if err != nil {
+		return services.ConversionResponse{Formula: formula}, err
+	}
+	s := services.ConversionService{}
+	s.Store = database.NewStore(db)
+	options := services.ConversionOptions{
+		Unit:      services.ConversionUnit(c.FormValue("unit")),
+		Element:   c.FormValue("element"),
+		Precision: precision,
+	}
+	return s.GetResponse(formula, quantity, options)
+}
+
+func ConversionPostHandler(c echo.Context) error {
+	response, err := conversionResponse(c)
+	page := conversionPage{
+		ConversionResponse: response,
+		Quantity:           c.FormValue("quantity"),
+		Unit:               c.FormValue("unit"),
+		Element:            c.FormValue("element"),
+	}
+	if err != nil {
+		page.Error = NewErrorResponse(err)
+		return c.Render(errorStatus(err), "conversion", page)
+	}
+	return c.Render(http.StatusOK, "conversion", page)
+}
+
+// ConversionAPIHandler returns the quantity in the form or query values in
+// grams, moles, particles and atoms as JSON, or an ErrorResponse on
+// failure.
+func ConversionAPIHandler(c echo.Context) error {
+	response, err := conversionResponse(c)
+	if err != nil {
+		return c.JSON(errorStatus(err), NewErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, response)
+}
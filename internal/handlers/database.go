@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"ChemistryPR/internal/config"
+	"ChemistryPR/internal/database"
+	"database/sql"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+// openDatabase opens the database of config for one request. A failure is
+// logged with its cause and returned as a 500 error that does not expose
+// the data source name.
+func openDatabase(config config.Config) (*sql.DB, func(), error) {
+	db, closeFunc, err := database.OpenDB(config.Driver, config.Dns)
+	if err != nil {
+		log.Errorf("cannot open %s database %q: %v", config.Driver, config.Dns, err)
+		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, "the database is unavailable")
+	}
+	return db, closeFunc, nil
+}
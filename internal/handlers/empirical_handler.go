@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"ChemistryPR/internal/config"
+	"ChemistryPR/internal/database"
+	"ChemistryPR/internal/services"
+	"net/http"
+	"os"
+
+	"github.com/labstack/echo/v4"
+)
+
+// empiricalPage is the data of the "empirical" template: the result or the
+// error of the last request.
+type empiricalPage struct {
+	services.EmpiricalFormulaResponse
+	MolarMass string
+	Error     *ErrorResponse
+}
+
+func EmpiricalGetHandler(c echo.Context) error {
+	config := config.LoadConfig()
+	content, err := os.ReadFile(config.Root + "/empirical.html")
+	if err != nil {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	return c.HTMLBlob(200, content)
+}
+
+// empiricalResponse computes the empirical formula of the "composition"
+// form value and, when the "molar_mass" form value is set, the molecular
+// formula too, rounded as the "precision" or "sigfigs" form value asks.
+func empiricalResponse(c echo.Context) (services.EmpiricalFormulaResponse, error) {
+	config := config.LoadConfig()
+	composition := c.FormValue("composition")
+	db, closeFunc, err := openDatabase(config)
+	if err != nil {
+		return services.EmpiricalFormulaResponse{Composition: composition}, err
+	}
+	defer closeFunc()
+	options, err := empiricalOptions(c)
+	if err != nil {
+		return services.EmpiricalFormulaResponse{Composition: composition}, err
+	}
+	s := services.EmpiricalFormulaService{}
+	s.Store = database.NewStore(db)
+	return s.GetResponse(composition, options)
+}
+
+// empiricalOptions reads the "molar_mass" form value and the rounding
+// option of an empirical formula request.
+func empiricalOptions(c echo.Context) (services.EmpiricalFormulaOptions, error) {
+	molarMass, err := formQuantity(c, "molar_mass")
+	if err != nil {
+		return services.EmpiricalFormulaOptions{}, err
+	}
+	precision, err := formPrecision(c)
+	return services.EmpiricalFormulaOptions{MolarMass: molarMass, Precision: precision}, err
+}
+
+func EmpiricalPostHandler(c echo.Context) error {
+	response, err := empiricalResponse(c)
+	page := empiricalPage{EmpiricalFormulaResponse: response, MolarMass: c.FormValue("molar_mass")}
+	if err != nil {
+		page.Error = NewErrorResponse(err)
+		return c.Render(errorStatus(err), "empirical", page)
+	}
+	return c.Render(http.StatusOK, "empirical", page)
+}
+
+// EmpiricalAPIHandler returns the empirical and molecular formulas of the
+// "composition" and "molar_mass" form or query values as JSON, or an
+// ErrorResponse on failure.
+func EmpiricalAPIHandler(c echo.Context) error {
+	response, err := empiricalResponse(c)
+	if err != nil {
+		return c.JSON(errorStatus(err), NewErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"ChemistryPR/internal/services"
+	"ChemistryPR/internal/units"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ErrorResponse describes a failed request, both on the HTML pages and in
+// the API. Syntax errors in a formula also carry the offending formula, the
+// column the parser stopped at and a caret pointing at it.
+type ErrorResponse struct {
+	Message  string
+	Input    string // Formula the parser failed on, empty for other errors
+	Column   int    // 1-based column of the error in Input, zero for other errors
+	Expected string // What the parser expected at Column
+	Found    string // What the parser found at Column
+	Caret    string // Input with a caret under Column on the next line
+
+	Suggestions map[string][]string // Known element symbols close to every unknown one
+}
+
+// NewErrorResponse converts an error returned by a service into an
+// ErrorResponse, unwrapping a *services.ParseError or a
+// *services.UnknownElementError when there is one.
+// The message of an *echo.HTTPError is used without its status code.
+func NewErrorResponse(err error) *ErrorResponse {
+	response := &ErrorResponse{Message: err.Error()}
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		response.Message = fmt.Sprint(httpErr.Message)
+	}
+	var parseErr *services.ParseError
+	if errors.As(err, &parseErr) {
+		response.Input = parseErr.Formula
+		response.Column = parseErr.Column
+		response.Expected = parseErr.Expected
+		response.Found = parseErr.Found
+		response.Caret = parseErr.Caret()
+	}
+	var unknownErr *services.UnknownElementError
+	if errors.As(err, &unknownErr) {
+		response.Suggestions = unknownErr.Suggestions
+	}
+	return response
+}
+
+// errorStatus returns the HTTP status of an error: an *echo.HTTPError keeps
+// its code, malformed formulas and equations, unknown elements and invalid
+// values are bad requests, and anything else, such as a failing store or
+// balancer backend, is an internal error.
+func errorStatus(err error) int {
+	var (
+		httpErr    *echo.HTTPError
+		parseErr   *services.ParseError
+		unknownErr *services.UnknownElementError
+		balanceErr *services.BalanceError
+		inputErr   *services.InputError
+	)
+	switch {
+	case errors.As(err, &httpErr):
+		return httpErr.Code
+	case errors.Is(err, services.ErrBalancerFailed):
+		return http.StatusInternalServerError
+	case errors.As(err, &parseErr), errors.As(err, &unknownErr), errors.As(err, &balanceErr), errors.As(err, &inputErr),
+		errors.Is(err, services.ErrPrecision), errors.Is(err, units.ErrUnknownUnit), errors.Is(err, units.ErrDimension):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
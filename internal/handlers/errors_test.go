@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"ChemistryPR/internal/services"
+	"ChemistryPR/internal/units"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "HTTP error", err: echo.NewHTTPError(http.StatusNotFound, "no page"), status: http.StatusNotFound},
+		{name: "parse error", err: &services.ParseError{Formula: "H2O)", Column: 4}, status: http.StatusBadRequest},
+		{name: "unknown element", err: &services.UnknownElementError{Formula: "Xx", Symbols: []string{"Xx"}}, status: http.StatusBadRequest},
+		{name: "unbalanceable", err: &services.BalanceError{Reason: services.ErrUnbalanceable}, status: http.StatusBadRequest},
+		{name: "invalid value", err: &services.InputError{Err: errors.New("volume must be positive")}, status: http.StatusBadRequest},
+		{name: "wrapped dimension", err: fmt.Errorf("density: %w", units.ErrDimension), status: http.StatusBadRequest},
+		{name: "precision", err: services.ErrPrecision, status: http.StatusBadRequest},
+		{name: "balancer backend", err: &services.BalanceError{Reason: services.ErrBalancerFailed}, status: http.StatusInternalServerError},
+		{name: "store", err: sql.ErrConnDone, status: http.StatusInternalServerError},
+		{name: "anything else", err: errors.New("disk full"), status: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		if status := errorStatus(test.err); status != test.status {
+			t.Errorf("%s: errorStatus(%v) = %d, want %d", test.name, test.err, status, test.status)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"ChemistryPR/internal/services"
+	"ChemistryPR/internal/units"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// formQuantity reads the form or query value name as a quantity such as
+// "25 mL" or "0.180 kg", keeping its significant figures. The unit may be
+// left out and is then up to the service. An empty value reads as zero;
+// anything else that is not a quantity is a 400 error.
+func formQuantity(c echo.Context, name string) (units.Quantity, error) {
+	value := strings.TrimSpace(c.FormValue(name))
+	if value == "" {
+		return units.Quantity{}, nil
+	}
+	quantity, err := units.Parse(value)
+	if err != nil {
+		return units.Quantity{}, echo.NewHTTPError(http.StatusBadRequest, name+": "+err.Error())
+	}
+	return quantity, nil
+}
+
+// formPrecision reads the rounding option shared by all calculators: the
+// "precision" form or query value asks for a number of decimal places, the
+// "sigfigs" value for a number of significant figures. Without either the
+// results follow the precision of the data.
+func formPrecision(c echo.Context) (services.Precision, error) {
+	decimals := strings.TrimSpace(c.FormValue("precision"))
+	figures := strings.TrimSpace(c.FormValue("sigfigs"))
+	precision := services.Precision{}
+	switch {
+	case decimals != "" && figures != "":
+		return precision, echo.NewHTTPError(http.StatusBadRequest, "precision and sigfigs cannot be used together")
+	case decimals != "":
+		precision.Rounding = services.DecimalRounding
+	case figures != "":
+		precision.Rounding, decimals = services.FigureRounding, figures
+	default:
+		return precision, nil
+	}
+	digits, err := strconv.Atoi(decimals)
+	if err != nil {
+		return precision, echo.NewHTTPError(http.StatusBadRequest, string(precision.Rounding)+" is not a whole number: "+decimals)
+	}
+	precision.Digits = digits
+	if err := precision.Validate(); err != nil {
+		return precision, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return precision, nil
+}
+
+// formGasConditions reads the conditions of gas volumes: the "conditions"
+// form or query value selects "stp" or "satp", and the "temperature" and
+// "pressure" values override either of them. Without any the conditions
+// are those of STP.
+func formGasConditions(c echo.Context) (services.GasConditions, error) {
+	var conditions services.GasConditions
+	switch preset := strings.TrimSpace(c.FormValue("conditions")); preset {
+	case "", "stp":
+		conditions = services.STP
+	case "satp":
+		conditions = services.SATP
+	default:
+		return conditions, echo.NewHTTPError(http.StatusBadRequest, "unknown gas conditions "+strconv.Quote(preset))
+	}
+	temperature, err := formQuantity(c, "temperature")
+	if err != nil {
+		return conditions, err
+	}
+	pressure, err := formQuantity(c, "pressure")
+	if err != nil {
+		return conditions, err
+	}
+	if temperature != (units.Quantity{}) {
+		conditions.Temperature = temperature
+	}
+	if pressure != (units.Quantity{}) {
+		conditions.Pressure = pressure
+	}
+	return conditions, nil
+}
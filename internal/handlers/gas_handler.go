@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"ChemistryPR/internal/config"
+	"ChemistryPR/internal/database"
+	"ChemistryPR/internal/services"
+	"ChemistryPR/internal/units"
+	"net/http"
+	"os"
+
+	"github.com/labstack/echo/v4"
+)
+
+// gasPage is the data of the "gas" template: the entered values and the
+// result or the error of the last request.
+type gasPage struct {
+	services.GasResponse
+	Input map[string]string
+	Error *ErrorResponse
+}
+
+// gasFields are the form values of a gas problem besides the rounding.
+var gasFields = []string{"law", "formula", "p", "v", "n", "t", "p2", "v2", "t2", "components"}
+
+func GasGetHandler(c echo.Context) error {
+	config := config.LoadConfig()
+	content, err := os.ReadFile(config.Root + "/gas.html")
+	if err != nil {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	return c.HTMLBlob(200, content)
+}
+
+// gasResponse solves the gas problem of the "law" form value: the "p",
+// "v", "n" and "t" values are the state, "p2", "v2" and "t2" the final
+// state of the combined gas law and "components" the gases of a mixture.
+// The "formula" value names the gas when amounts are masses.
+func gasResponse(c echo.Context) (services.GasResponse, error) {
+	config := config.LoadConfig()
+	db, closeFunc, err := openDatabase(config)
+	if err != nil {
+		return services.GasResponse{}, err
+	}
+	defer closeFunc()
+	values := make(map[string]units.Quantity)
+	for _, field := range []string{"p", "v", "n", "t", "p2", "v2", "t2"} {
+		if values[field], err = formQuantity(c, field); err != nil {
+			return services.GasResponse{}, err
+		}
+	}
+	precision, err := formPrecision(c)
+	if err != nil {
+		return services.GasResponse{}, err
+	}
+	input := services.GasInput{
+		Law:     services.GasLaw(c.FormValue("law")),
+		Formula: c.FormValue("formula"),
+		State: services.GasState{
+			Pressure:    values["p"],
+			Volume:      values["v"],
+			Amount:      values["n"],
+			Temperature: values["t"],
+		},
+		Final: services.GasState{
+			Pressure:    values["p2"],
+			Volume:      values["v2"],
+			Temperature: values["t2"],
+		},
+		Components: c.FormValue("components"),
+	}
+	s := services.GasService{}
+	s.Store = database.NewStore(db)
+	return s.GetResponse(input, precision)
+}
+
+func GasPostHandler(c echo.Context) error {
+	response, err := gasResponse(c)
+	page := gasPage{GasResponse: response, Input: make(map[string]string)}
+	for _, field := range gasFields {
+		page.Input[field] = c.FormValue(field)
+	}
+	if err != nil {
+		page.Error = NewErrorResponse(err)
+		return c.Render(errorStatus(err), "gas", page)
+	}
+	return c.Render(http.StatusOK, "gas", page)
+}
+
+// GasAPIHandler solves the gas problem in the form or query values and
+// returns the GasResponse as JSON, or an ErrorResponse on failure.
+func GasAPIHandler(c echo.Context) error {
+	response, err := gasResponse(c)
+	if err != nil {
+		return c.JSON(errorStatus(err), NewErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, response)
+}
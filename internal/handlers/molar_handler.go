@@ -4,11 +4,12 @@ import (
 	"ChemistryPR/internal/config"
 	"ChemistryPR/internal/database"
 	"ChemistryPR/internal/services"
+	"html/template"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/gommon/log"
 )
 
 type ElementInfo struct {
@@ -22,6 +23,14 @@ type ElementDiscription struct {
 	Elements []ElementInfo
 }
 
+// molarPage is the data of the "molar" template: the result or the error
+// of the last request and, in isotopic mode, the stick spectrum.
+type molarPage struct {
+	services.MolarMassResponse
+	Error    *ErrorResponse
+	Spectrum template.HTML
+}
+
 func MolarGetHandler(c echo.Context) error {
 	config := config.LoadConfig()
 	content, err := os.ReadFile(config.Root + "/molar.html")
@@ -31,20 +40,48 @@ func MolarGetHandler(c echo.Context) error {
 	return c.HTMLBlob(200, content)
 }
 
-func MolarPostHandler(c echo.Context) error {
+// molarResponse computes the molar mass of the "formula" form value, or of
+// the "smiles" form value when it is set, in the mode given by the "mode"
+// form value and rounded as the "precision" or "sigfigs" form value asks.
+func molarResponse(c echo.Context) (services.MolarMassResponse, error) {
 	config := config.LoadConfig()
-	db, closeFunc, err := database.OpenDB(config.Driver, config.Dns)
+	formula := c.FormValue("formula")
+	if smiles := strings.TrimSpace(c.FormValue("smiles")); smiles != "" {
+		formula = services.SMILESPrefix + smiles
+	}
+	db, closeFunc, err := openDatabase(config)
 	if err != nil {
-		log.Debug("pizda bd nakrilas")
-		panic("pizda bd nakrilas")
+		return services.MolarMassResponse{Formula: formula}, err
 	}
 	defer closeFunc()
-	formula := c.FormValue("formula")
+	precision, err := formPrecision(c)
+	if err != nil {
+		return services.MolarMassResponse{Formula: formula}, err
+	}
 	s := services.MolarMassService{}
 	s.Store = database.NewStore(db)
-	response, err := s.GetResponse(formula)
+	options := services.MolarMassOptions{Mode: services.MolarMassMode(c.FormValue("mode")), Precision: precision}
+	response, err := s.GetResponse(formula, options)
+	if err != nil {
+		response.Formula = formula
+	}
+	return response, err
+}
+
+func MolarPostHandler(c echo.Context) error {
+	response, err := molarResponse(c)
+	if err != nil {
+		return c.Render(errorStatus(err), "molar", molarPage{MolarMassResponse: response, Error: NewErrorResponse(err)})
+	}
+	return c.Render(http.StatusOK, "molar", molarPage{MolarMassResponse: response, Spectrum: spectrumSVG(response.Isotopic)})
+}
+
+// MolarAPIHandler returns the molar mass of the "formula" form or query
+// value as JSON, or an ErrorResponse on failure.
+func MolarAPIHandler(c echo.Context) error {
+	response, err := molarResponse(c)
 	if err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return c.JSON(errorStatus(err), NewErrorResponse(err))
 	}
-	return c.Render(http.StatusOK, "molar", response)
+	return c.JSON(http.StatusOK, response)
 }
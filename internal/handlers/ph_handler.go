@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"ChemistryPR/internal/config"
+	"ChemistryPR/internal/database"
+	"ChemistryPR/internal/services"
+	"net/http"
+	"os"
+
+	"github.com/labstack/echo/v4"
+)
+
+// phPage is the data of the "ph" template: the entered solution and the
+// result or the error of the last request.
+type phPage struct {
+	services.AcidBaseResponse
+	Input string
+	Error *ErrorResponse
+}
+
+func PHGetHandler(c echo.Context) error {
+	config := config.LoadConfig()
+	content, err := os.ReadFile(config.Root + "/ph.html")
+	if err != nil {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	return c.HTMLBlob(200, content)
+}
+
+// phResponse computes the pH of the "solution" form value, written as
+// "CH3COOH 0.1 M; CH3COONa 0.1 M".
+func phResponse(c echo.Context) (services.AcidBaseResponse, error) {
+	config := config.LoadConfig()
+	db, closeFunc, err := openDatabase(config)
+	if err != nil {
+		return services.AcidBaseResponse{}, err
+	}
+	defer closeFunc()
+	precision, err := formPrecision(c)
+	if err != nil {
+		return services.AcidBaseResponse{}, err
+	}
+	s := services.AcidBaseService{}
+	s.Store = database.NewStore(db)
+	return s.GetResponse(c.FormValue("solution"), precision)
+}
+
+func PHPostHandler(c echo.Context) error {
+	response, err := phResponse(c)
+	page := phPage{AcidBaseResponse: response, Input: c.FormValue("solution")}
+	if err != nil {
+		page.Error = NewErrorResponse(err)
+		return c.Render(errorStatus(err), "ph", page)
+	}
+	return c.Render(http.StatusOK, "ph", page)
+}
+
+// PHAPIHandler computes the pH of the solution in the form or query values
+// and returns the AcidBaseResponse as JSON, or an ErrorResponse on failure.
+func PHAPIHandler(c echo.Context) error {
+	response, err := phResponse(c)
+	if err != nil {
+		return c.JSON(errorStatus(err), NewErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"ChemistryPR/internal/config"
+	"ChemistryPR/internal/database"
+	"ChemistryPR/internal/services"
+	"ChemistryPR/internal/units"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// solutionPage is the data of the "solution" template: the entered values
+// and the result or the error of the last request.
+type solutionPage struct {
+	services.SolutionResponse
+	Input map[string]string
+	Error *ErrorResponse
+}
+
+// solutionFields are the form values of a solution problem besides the
+// rounding.
+var solutionFields = []string{"formula", "concentration", "density", "record_density", "equivalents", "volume", "c1", "v1", "c2", "v2"}
+
+func SolutionGetHandler(c echo.Context) error {
+	config := config.LoadConfig()
+	content, err := os.ReadFile(config.Root + "/solution.html")
+	if err != nil {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	return c.HTMLBlob(200, content)
+}
+
+// solutionResponse converts the "concentration" form value of the solute
+// "formula", with the optional "density" and "equivalents", prepares the
+// "volume" of the solution and solves the dilution of the "c1", "v1",
+// "c2" and "v2" values. A non-empty "record_density" takes the density
+// from the compound record when none is given.
+func solutionResponse(c echo.Context) (services.SolutionResponse, error) {
+	config := config.LoadConfig()
+	db, closeFunc, err := openDatabase(config)
+	if err != nil {
+		return services.SolutionResponse{}, err
+	}
+	defer closeFunc()
+	values := make(map[string]units.Quantity)
+	for _, field := range []string{"concentration", "density", "volume", "c1", "v1", "c2", "v2"} {
+		if values[field], err = formQuantity(c, field); err != nil {
+			return services.SolutionResponse{}, err
+		}
+	}
+	precision, err := formPrecision(c)
+	if err != nil {
+		return services.SolutionResponse{}, err
+	}
+	equivalents := 0
+	if value := strings.TrimSpace(c.FormValue("equivalents")); value != "" {
+		if equivalents, err = strconv.Atoi(value); err != nil {
+			return services.SolutionResponse{}, echo.NewHTTPError(http.StatusBadRequest, "equivalents is not a whole number: "+value)
+		}
+	}
+	input := services.SolutionInput{
+		Formula:       c.FormValue("formula"),
+		Concentration: values["concentration"],
+		Density:       values["density"],
+		RecordDensity: c.FormValue("record_density") != "",
+		Equivalents:   equivalents,
+		Volume:        values["volume"],
+		Dilution: services.Dilution{
+			Initial:       values["c1"],
+			InitialVolume: values["v1"],
+			Final:         values["c2"],
+			FinalVolume:   values["v2"],
+		},
+	}
+	s := services.SolutionService{}
+	s.Store = database.NewStore(db)
+	return s.GetResponse(input, precision)
+}
+
+func SolutionPostHandler(c echo.Context) error {
+	response, err := solutionResponse(c)
+	page := solutionPage{SolutionResponse: response, Input: make(map[string]string)}
+	for _, field := range solutionFields {
+		page.Input[field] = c.FormValue(field)
+	}
+	if err != nil {
+		page.Error = NewErrorResponse(err)
+		return c.Render(errorStatus(err), "solution", page)
+	}
+	return c.Render(http.StatusOK, "solution", page)
+}
+
+// SolutionAPIHandler solves the solution problem in the form or query
+// values and returns the SolutionResponse as JSON, or an ErrorResponse on
+// failure.
+func SolutionAPIHandler(c echo.Context) error {
+	response, err := solutionResponse(c)
+	if err != nil {
+		return c.JSON(errorStatus(err), NewErrorResponse(err))
+	}
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"ChemistryPR/internal/services"
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+)
+
+// Dimensions of the stick spectrum in SVG user units.
+const (
+	spectrumWidth  = 480
+	spectrumHeight = 240
+	spectrumMargin = 40
+)
+
+// spectrumSVG draws the peaks of an isotope pattern as a stick spectrum:
+// m/z on the horizontal axis, relative intensity on the vertical one, every
+// stick labelled with its peak. It returns an empty string for nil.
+func spectrumSVG(pattern *services.IsotopePattern) template.HTML {
+	if pattern == nil || len(pattern.Peaks) == 0 {
+		return ""
+	}
+
+	low := math.Floor(pattern.Peaks[0].Mass) - 1
+	high := math.Ceil(pattern.Peaks[len(pattern.Peaks)-1].Mass) + 1
+	plotWidth := float64(spectrumWidth - 2*spectrumMargin)
+	plotHeight := float64(spectrumHeight - 2*spectrumMargin)
+	x := func(mass float64) float64 {
+		return spectrumMargin + (mass-low)/(high-low)*plotWidth
+	}
+	y := func(intensity float64) float64 {
+		return spectrumHeight - spectrumMargin - intensity/100*plotHeight
+	}
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg class="molar-mass__spectrum" xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" role="img" aria-label="Изотопный спектр">`,
+		spectrumWidth, spectrumHeight)
+	fmt.Fprintf(&svg, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="currentColor"/>`,
+		spectrumMargin, y(0), spectrumWidth-spectrumMargin, y(0))
+	fmt.Fprintf(&svg, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="currentColor"/>`,
+		spectrumMargin, y(0), spectrumMargin, y(100))
+	fmt.Fprintf(&svg, `<text x="%d" y="%.1f" font-size="10" text-anchor="end">100</text>`, spectrumMargin-4, y(100)+4)
+	fmt.Fprintf(&svg, `<text x="%d" y="%.1f" font-size="10" text-anchor="end">0</text>`, spectrumMargin-4, y(0)+4)
+	for _, peak := range pattern.Peaks {
+		fmt.Fprintf(&svg, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="currentColor" stroke-width="2"/>`,
+			x(peak.Mass), y(0), x(peak.Mass), y(peak.Intensity))
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%.1f" font-size="10" text-anchor="middle">%s</text>`,
+			x(peak.Mass), y(peak.Intensity)-4, template.HTMLEscapeString(peak.Label))
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%.1f" font-size="10" text-anchor="middle">%.2f</text>`,
+			x(peak.Mass), y(0)+14, peak.Mass)
+	}
+	svg.WriteString(`</svg>`)
+	return template.HTML(svg.String())
+}
@@ -0,0 +1,15 @@
+package models
+
+// AcidBaseStep is one proton-transfer step of an acid or a base in water.
+type AcidBaseStep struct {
+	Formula string  // The neutral compound as written in the table, e.g., "H3PO4"
+	Name    string  // The name of the compound
+	Kind    string  // "acid" for a proton donor, "base" for a proton acceptor
+	Step    int     // The number of the step from the neutral compound, e.g., 2 for H2PO4^- giving HPO4^2-
+	Acid    string  // The proton donor of the step, e.g., "H2PO4^-"
+	Base    string  // The proton acceptor of the step, e.g., "HPO4^2-"
+	Strong  bool    // The step goes to completion in water
+	Ka      float64 // The acid dissociation constant of Acid, e.g., 6.31e-8; zero when unknown
+	Kb      float64 // The base dissociation constant of Base, e.g., 1.58e-7; zero when unknown
+	PKa     float64 // The negative decimal logarithm of Ka, e.g., 7.2; zero when unknown
+}
@@ -2,8 +2,22 @@ package models
 
 // Compound represents a chemical compound with its formula and constituent elements.
 type Compound struct {
-	Formula    string         // The chemical formula of the compound, e.g., "H2O" for water
-	Name       string         // The name of chemical compound
-	Appearance string         // The appearance of chemical compound
-	Data       map[string]int // A map containing the elements and their respective counts in the compound
+	Formula    string          // The chemical formula of the compound, e.g., "H2O" for water
+	Expanded   string          // The formula with functional-group abbreviations written out, e.g., "(C2H5)2O" for "Et2O"
+	Name       string          // The name of chemical compound
+	Appearance string          // The appearance of chemical compound
+	Density    float64         // The density of the pure compound in g/mL, e.g., 0.9982 for water; zero when unknown
+	Data       map[string]int  // A map containing the elements and their respective counts in the compound
+	Charge     int             // The net charge of the species, e.g., -2 for "SO4^2-" and -1 for an electron "e-"
+	Adducts    []Adduct        // The dot-joined parts of hydrates and adducts, e.g., "CuSO4" and "5H2O"; empty for simple formulas
+	Isotopes   map[Nuclide]int // The labelled atoms, e.g., 2H: 2 for "D2O"; they are also counted in Data under their element
+	State      string          // The state label the species was written with, e.g., "g" for "CO2(g)"; empty when none
+}
+
+// Adduct is one dot-joined part of a formula such as "CuSO4·5H2O".
+type Adduct struct {
+	Formula    string          // The formula of the part without its multiplier, e.g., "H2O"
+	Multiplier int             // The leading multiplier of the part, e.g., 5 for "5H2O"
+	Data       map[string]int  // The element counts of a single unit of the part
+	Isotopes   map[Nuclide]int // The labelled atoms of a single unit of the part
 }
@@ -5,4 +5,7 @@ type Element struct {
 	Name         string  // The name of the element
 	Symbol       string  // The symbol of the element, e.g., "H" for hydrogen
 	AtomicWeight float64 // The atomic weight of the element, e.g., 1.008 for hydrogen
+	Uncertainty  float64 // The uncertainty of the atomic weight, e.g., 0.0002 for hydrogen; zero when unknown
+	IntervalMin  float64 // The lower bound of the IUPAC interval weight, e.g., 1.00784 for hydrogen; zero when the weight is not an interval
+	IntervalMax  float64 // The upper bound of the IUPAC interval weight, e.g., 1.00811 for hydrogen; zero when the weight is not an interval
 }
@@ -0,0 +1,21 @@
+package models
+
+import "fmt"
+
+// Nuclide identifies an isotope of an element by its mass number.
+type Nuclide struct {
+	Symbol     string // The symbol of the element, e.g., "C"
+	MassNumber int    // The number of protons and neutrons, e.g., 13 for carbon-13
+}
+
+// String returns the nuclide in the notation of the formula parser, e.g., "13C".
+func (nuclide Nuclide) String() string {
+	return fmt.Sprintf("%d%s", nuclide.MassNumber, nuclide.Symbol)
+}
+
+// Isotope represents a nuclide with its exact mass and natural abundance.
+type Isotope struct {
+	Nuclide
+	ExactMass float64 // The atomic mass of the nuclide, e.g., 13.003355 for carbon-13
+	Abundance float64 // The fraction of the element's atoms found in nature, 0 for radionuclides such as tritium
+}
@@ -0,0 +1,410 @@
+package services
+
+import (
+	"ChemistryPR/internal/models"
+	"ChemistryPR/internal/units"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// AcidBaseService computes the pH of aqueous solutions of acids, bases
+// and their salts and the distribution of every acid or base among its
+// protonated forms. It embeds the ChemicalService to parse the solutes
+// and to look up their dissociation constants in the acid_base table.
+type AcidBaseService struct {
+	ChemicalService
+}
+
+// WaterIonProduct is the ion product of water, [H3O+][OH-], at 25 °C. It
+// is taken as exact and so does not limit significant figures.
+const WaterIonProduct = 1.0e-14
+
+// AcidBaseResponse is the equilibrium of a solution of acids and bases.
+type AcidBaseResponse struct {
+	Solution  string               // The solutes as entered, e.g. "CH3COOH 0.1 M"
+	PH        string               // e.g. "2.88"
+	POH       string               // e.g. "11.12"
+	Hydronium string               // Concentration of H3O+, e.g. "0.0013 M"
+	Hydroxide string               // Concentration of OH-
+	Systems   []AcidBaseSystemInfo // Acids and bases in the order they were entered
+}
+
+// AcidBaseSystemInfo is one acid or base of the solution with all its
+// protonated forms.
+type AcidBaseSystemInfo struct {
+	Formula       string                // Neutral compound as written in the acid_base table, e.g. "H3PO4"
+	Name          string                // Name of the compound
+	Kind          string                // "acid" or "base"
+	Strong        bool                  // Every step goes to completion
+	Concentration string                // Total concentration of all its forms
+	Steps         []AcidBaseStepInfo    // Proton-transfer steps from the table
+	Species       []AcidBaseSpeciesInfo // Forms present at equilibrium, most protonated first
+}
+
+// AcidBaseStepInfo is one proton-transfer step of an acid or a base.
+type AcidBaseStepInfo struct {
+	Step   string
+	Acid   string // Proton donor of the step
+	Base   string // Proton acceptor of the step
+	Strong bool   // The step goes to completion
+	Ka     string // Empty when unknown
+	PKa    string // Empty when unknown
+	Kb     string // Empty when unknown
+}
+
+// AcidBaseSpeciesInfo is the share of one form of an acid or a base.
+type AcidBaseSpeciesInfo struct {
+	Formula       string
+	Fraction      string // Share of the total concentration of the compound
+	Concentration string
+}
+
+// acidBaseSystem is an acid or a base of the solution reduced to the forms
+// present at equilibrium: strong steps are complete, so the forms they
+// consume are left out.
+type acidBaseSystem struct {
+	canonical string                // Canonical formula of the neutral compound
+	steps     []models.AcidBaseStep // All steps, from the table
+	forms     []string              // Forms present, most protonated first
+	charges   []int                 // Charge of every form
+	ka        []float64             // Acid constant between each form and the next
+	total     float64               // Total concentration in M
+	figures   int                   // Significant figures of total
+}
+
+// spectatorAnions are the anions of strong acids that take no part in
+// proton transfer, by their element counts.
+var spectatorAnions = []map[string]int{{"Cl": 1}, {"Br": 1}, {"I": 1}, {"N": 1, "O": 3}, {"Cl": 1, "O": 4}}
+
+// pHRange bounds the pH the charge balance is solved in.
+var pHRange = [2]float64{-3, 17}
+
+// GetResponse computes the pH of a solution written as "CH3COOH 0.1 M",
+// or as several solutes separated by semicolons, or by commas when there
+// are no semicolons, as in "CH3COOH 0.1 M; CH3COONa 0.1 M". Bare numbers
+// are concentrations in M.
+//
+// A solute is found in the acid_base table by the canonical formula of its
+// neutral compound. An ion such as "CH3COO-" or "NH4+" is found through
+// the compound it is a form of, and stands for its salt with a spectator
+// counter-ion. A salt such as "NaHCO3", "Na2HPO4" or "NH4Cl" is split into
+// such an ion and spectator ions of alkali and alkaline-earth metals or of
+// strong acids.
+//
+// The pH is the root of the charge balance of the solution, with the
+// fractions of every form following from the dissociation constants of the
+// table; this covers strong and weak acids and bases, polyprotic ones,
+// salts and buffers alike, without activity corrections. The pH keeps as
+// many decimal places as the least precise concentration or constant has
+// significant figures.
+func (service AcidBaseService) GetResponse(solution string, precision Precision) (AcidBaseResponse, error) {
+	response := AcidBaseResponse{Solution: strings.TrimSpace(solution)}
+	if err := precision.Validate(); err != nil {
+		return response, err
+	}
+	separator := ","
+	if strings.Contains(solution, ";") {
+		separator = ";"
+	}
+
+	var systems []*acidBaseSystem
+	spectators := 0.0
+	figures := maxDigits
+	for _, entry := range strings.Split(solution, separator) {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) == 1 {
+			return response, inputErrorf("give the concentration of %s, e.g. \"CH3COOH 0.1 M\"", fields[0])
+		}
+		concentration, err := units.Parse(strings.Join(fields[1:], " "))
+		if err != nil {
+			return response, inputErrorf("concentration of %s: %w", fields[0], err)
+		}
+		if concentration, err = concentration.Default(units.Molar).In(units.Molar); err != nil {
+			return response, inputErrorf("concentration of %s: %w", fields[0], err)
+		}
+		if concentration.Value <= 0 {
+			return response, inputErrorf("concentration of %s must be positive, got %s", fields[0], concentration)
+		}
+		system, charge, count, err := service.findAcidBase(fields[0])
+		if err != nil {
+			return response, err
+		}
+		total := concentration.Value * float64(count)
+		// Whatever charge the acid or base carries as dissolved is balanced
+		// by its spectator counter-ions.
+		spectators -= total * float64(charge)
+		figures = min(figures, concentration.Figures)
+		merged := false
+		for _, other := range systems {
+			if other.canonical == system.canonical {
+				other.total += total
+				other.figures = min(other.figures, concentration.Figures)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			system.total, system.figures = total, concentration.Figures
+			systems = append(systems, system)
+		}
+	}
+	if len(systems) == 0 {
+		return response, inputErrorf("no solute given, e.g. \"CH3COOH 0.1 M\"")
+	}
+	for _, system := range systems {
+		for _, ka := range system.ka {
+			_, places := exactDecimal(ka)
+			figures = min(figures, significantPlaces(ka, places))
+		}
+	}
+
+	balance := func(pH float64) float64 {
+		h := math.Pow(10, -pH)
+		sum := h - WaterIonProduct/h + spectators
+		for _, system := range systems {
+			for i, fraction := range system.fractions(pH) {
+				sum += system.total * fraction * float64(system.charges[i])
+			}
+		}
+		return sum
+	}
+	low, high := pHRange[0], pHRange[1]
+	if balance(low) < 0 || balance(high) > 0 {
+		return response, inputErrorf("the pH of the solution is outside %g to %g", low, high)
+	}
+	// The balance falls as the pH rises: the higher the pH, the fewer
+	// protons every form holds.
+	for range 100 {
+		middle := (low + high) / 2
+		if balance(middle) > 0 {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	pH := (low + high) / 2
+	pKw := -math.Log10(WaterIonProduct)
+
+	formatP := func(value float64) string {
+		exact, _ := exactDecimal(value)
+		return precision.FormatExact(exact, figures)
+	}
+	formatMolar := func(value float64, figures int) string {
+		return units.Join(formatQuantity(precision, value, figures), units.Molar)
+	}
+	response.PH = formatP(pH)
+	response.POH = formatP(pKw - pH)
+	response.Hydronium = formatMolar(math.Pow(10, -pH), figures)
+	response.Hydroxide = formatMolar(math.Pow(10, pH-pKw), figures)
+	for _, system := range systems {
+		response.Systems = append(response.Systems, system.info(precision, pH, figures))
+	}
+	return response, nil
+}
+
+// findAcidBase finds the acid or base formula is a form or a salt of. It
+// returns the system, the charge of the form dissolved and how many units
+// of it one unit of formula gives.
+func (service AcidBaseService) findAcidBase(formula string) (*acidBaseSystem, int, int, error) {
+	compound, err := service.ParseCompound(formula)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if system, err := service.acidBaseForm(compound.Data, compound.Charge); system != nil || err != nil {
+		return system, compound.Charge, 1, err
+	}
+	if compound.Charge != 0 {
+		return nil, 0, 0, inputErrorf("%s is not a form of any acid or base in the acid_base table", formula)
+	}
+
+	// try splits the salt into the ion left by removing spectators of
+	// total charge, divided into count units.
+	try := func(rest map[string]int, charge int) (*acidBaseSystem, int, int, error) {
+		for count := int(abs(int64(charge))); count >= 1; count-- {
+			if charge%count != 0 {
+				continue
+			}
+			ion, ok := divideCounts(rest, count)
+			if !ok {
+				continue
+			}
+			system, err := service.acidBaseForm(ion, charge/count)
+			if system != nil || err != nil {
+				return system, charge / count, count, err
+			}
+		}
+		return nil, 0, 0, nil
+	}
+	for _, cation := range spectatorCations {
+		for removed := compound.Data[cation.symbol]; removed >= 1; removed-- {
+			rest := removeCounts(compound.Data, map[string]int{cation.symbol: 1}, removed)
+			if system, charge, count, err := try(rest, -removed*cation.charge); system != nil || err != nil {
+				return system, charge, count, err
+			}
+		}
+	}
+	for _, anion := range spectatorAnions {
+		for removed := 1; ; removed++ {
+			rest := removeCounts(compound.Data, anion, removed)
+			if rest == nil {
+				break
+			}
+			if system, charge, count, err := try(rest, removed); system != nil || err != nil {
+				return system, charge, count, err
+			}
+		}
+	}
+	return nil, 0, 0, inputErrorf("%s is not in the acid_base table, nor a form or a salt of an acid or base in it", formula)
+}
+
+// acidBaseForm looks up the acid or base whose neutral compound differs
+// from the species of counts and charge by charge protons, and returns its
+// system when the species is one of its forms, or nil.
+func (service AcidBaseService) acidBaseForm(counts map[string]int, charge int) (*acidBaseSystem, error) {
+	neutral := make(map[string]int, len(counts)+1)
+	for symbol, count := range counts {
+		neutral[symbol] = count
+	}
+	neutral["H"] -= charge
+	if neutral["H"] < 0 || len(counts) == 0 {
+		return nil, nil
+	}
+	if neutral["H"] == 0 {
+		delete(neutral, "H")
+	}
+	steps, err := service.Store.GetAcidBase(CanonicalFormula(models.Compound{Data: neutral}))
+	if err != nil || len(steps) == 0 {
+		return nil, err
+	}
+	species := CanonicalFormula(models.Compound{Data: counts, Charge: charge})
+	system, err := service.acidBaseSystem(steps)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range steps {
+		for _, form := range []string{step.Acid, step.Base} {
+			if canonical, err := service.Canonicalize(form); err == nil && canonical == species {
+				return system, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// acidBaseSystem orders the forms of an acid or a base by the protons they
+// hold and leaves out those that strong steps use up.
+func (service AcidBaseService) acidBaseSystem(steps []models.AcidBaseStep) (*acidBaseSystem, error) {
+	// Steps in the order protons are given up: an acid gives them from
+	// the neutral compound on, a base's conjugate acids give them back to
+	// it last.
+	pairs := make([]models.AcidBaseStep, len(steps))
+	copy(pairs, steps)
+	if steps[0].Kind == "base" {
+		for i, j := 0, len(pairs)-1; i < j; i, j = i+1, j-1 {
+			pairs[i], pairs[j] = pairs[j], pairs[i]
+		}
+	}
+	first, last := 0, len(pairs)
+	if steps[0].Kind == "base" {
+		for last > 0 && pairs[last-1].Strong {
+			last--
+		}
+	} else {
+		for first < len(pairs) && pairs[first].Strong {
+			first++
+		}
+	}
+
+	canonical, err := service.Canonicalize(steps[0].Formula)
+	if err != nil {
+		return nil, err
+	}
+	system := &acidBaseSystem{canonical: canonical, steps: steps}
+	forms := []string{pairs[0].Acid}
+	for _, pair := range pairs {
+		forms = append(forms, pair.Base)
+	}
+	for i, form := range forms[first : last+1] {
+		compound, err := service.ParseCompound(form)
+		if err != nil {
+			return nil, err
+		}
+		system.forms = append(system.forms, form)
+		system.charges = append(system.charges, compound.Charge)
+		if i > 0 {
+			system.ka = append(system.ka, pairs[first+i-1].Ka)
+		}
+	}
+	return system, nil
+}
+
+// fractions returns the share of every form of system at pH.
+func (system acidBaseSystem) fractions(pH float64) []float64 {
+	// Each form holds one proton less than the one before, so its share
+	// relative to that form is Ka/[H3O+]; work in logarithms to keep the
+	// products of polyprotic acids in range.
+	logs := make([]float64, len(system.forms))
+	largest := 0.0
+	for i := 1; i < len(logs); i++ {
+		logs[i] = logs[i-1] + math.Log10(system.ka[i-1]) + pH
+		largest = max(largest, logs[i])
+	}
+	fractions := make([]float64, len(logs))
+	sum := 0.0
+	for i, log := range logs {
+		fractions[i] = math.Pow(10, log-largest)
+		sum += fractions[i]
+	}
+	for i := range fractions {
+		fractions[i] /= sum
+	}
+	return fractions
+}
+
+// info writes system out with the distribution of its forms at pH, known
+// to figures.
+func (system acidBaseSystem) info(precision Precision, pH float64, figures int) AcidBaseSystemInfo {
+	info := AcidBaseSystemInfo{
+		Formula:       system.steps[0].Formula,
+		Name:          system.steps[0].Name,
+		Kind:          system.steps[0].Kind,
+		Strong:        true,
+		Concentration: units.Join(formatQuantity(precision, system.total, system.figures), units.Molar),
+	}
+	constant := func(value float64) string {
+		if value == 0 {
+			return ""
+		}
+		_, places := exactDecimal(value)
+		return formatQuantity(precision, value, significantPlaces(value, places))
+	}
+	for _, step := range system.steps {
+		info.Strong = info.Strong && step.Strong
+		pKa := ""
+		if step.Ka != 0 {
+			exact, places := exactDecimal(step.PKa)
+			pKa = precision.FormatExact(exact, places)
+		}
+		info.Steps = append(info.Steps, AcidBaseStepInfo{
+			Step:   fmt.Sprint(step.Step),
+			Acid:   step.Acid,
+			Base:   step.Base,
+			Strong: step.Strong,
+			Ka:     constant(step.Ka),
+			PKa:    pKa,
+			Kb:     constant(step.Kb),
+		})
+	}
+	for i, fraction := range system.fractions(pH) {
+		info.Species = append(info.Species, AcidBaseSpeciesInfo{
+			Formula:       system.forms[i],
+			Fraction:      formatQuantity(precision, fraction, figures),
+			Concentration: units.Join(formatQuantity(precision, system.total*fraction, figures), units.Molar),
+		})
+	}
+	return info
+}
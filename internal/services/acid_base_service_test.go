@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestAcidBase(t *testing.T) {
+	tests := []struct {
+		name     string
+		solution string
+		pH       string
+	}{
+		{name: "weak acid", solution: "CH3COOH 0.1 M", pH: "2.9"},
+		{name: "buffer", solution: "CH3COOH 0.1 M; CH3COONa 0.1 M", pH: "4.8"},
+		{name: "acidic salt", solution: "NH4Cl 0.1 M", pH: "5.1"},
+		{name: "basic salt", solution: "Na2CO3 0.1 M", pH: "11.7"},
+		{name: "strong acid", solution: "HCl 0.010 M", pH: "2.00"},
+		{name: "strong base", solution: "NaOH 0.010 M", pH: "12.00"},
+		{name: "weak base", solution: "NH3 0.10 M", pH: "11.12"},
+		{name: "ion as its salt", solution: "CH3COO- 0.1 M", pH: "8.9"},
+	}
+
+	service := AcidBaseService{testService(t)}
+	for _, test := range tests {
+		response, err := service.GetResponse(test.solution, Precision{})
+		if err != nil {
+			t.Errorf("GetResponse(%q): %v", test.solution, err)
+			continue
+		}
+		if response.PH != test.pH {
+			t.Errorf("GetResponse(%q) pH = %s, want %s", test.solution, response.PH, test.pH)
+		}
+	}
+}
+
+func TestAcidBaseErrors(t *testing.T) {
+	tests := []string{
+		"CH3COOH",
+		"CH3COOH 0 M",
+		"CH3COOH 0.1 g",
+		"C6H12O6 0.1 M",
+	}
+
+	service := AcidBaseService{testService(t)}
+	for _, solution := range tests {
+		if _, err := service.GetResponse(solution, Precision{}); err == nil {
+			t.Errorf("GetResponse(%q) succeeded, want an error", solution)
+		}
+	}
+}
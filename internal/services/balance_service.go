@@ -1,14 +1,15 @@
 package services
 
 import (
-	"bytes"
-	"os/exec"
+	"ChemistryPR/internal/units"
 	"strings"
 )
 
-// BalanceService handles chemical balancing operations.  It relies on a ChemicalService for underlying chemical information.
+// BalanceService handles chemical balancing operations.  It relies on a ChemicalService for underlying chemical information
+// and on a Balancer for finding the coefficients. A nil Balancer falls back to the NativeBalancer.
 type BalanceService struct {
 	ChemicalService
+	Balancer Balancer
 }
 
 // BalanceCompoundInfo represents information about a compound involved in a balanced reaction.
@@ -18,15 +19,50 @@ type BalanceCompoundInfo struct {
 	Appearance string // Physical appearance (e.g., "Clear liquid")
 }
 
+// BalanceMode selects how an equation is balanced.
+type BalanceMode string
+
+const (
+	AlgebraicMode BalanceMode = "algebraic" // Nullspace of the composition matrix, done by the configured Balancer
+	RedoxMode     BalanceMode = "redox"     // Half-reaction method with oxidation-state tracking
+)
+
+// BalanceOptions holds the optional parameters of a balancing request. The
+// zero value balances algebraically.
+type BalanceOptions struct {
+	Mode    BalanceMode // Balancing method, AlgebraicMode when empty
+	Medium  Medium      // Medium of the half-reactions in RedoxMode
+	Pins    string      // Coefficients fixed by the user in AlgebraicMode, e.g. "H2O=2, O2=1"
+	Explain bool        // Return the working of the algebraic balancing
+
+	Given     string         // Species whose amount is given, as written or by canonical formula; the first reagent when empty
+	Amount    units.Quantity // Amount of Given in g, mol or L of gas; no stoichiometry is computed when zero
+	Available string         // Available amounts of the reagents, e.g. "H2=4 g, O2=16 g"; no yield is computed when empty
+	Actual    string         // Actual yields of the products, e.g. "H2O=15 g"
+	Gas       GasConditions  // Conditions of the gas volumes of the stoichiometry and the yield, STP when zero
+	Precision Precision      // Rounding of the stoichiometry and the yield
+}
+
 // BalanceResponse represents the response from a chemical balancing request.
 type BalanceResponse struct {
 	Reaction string
 	Result   string                // Balanced equation
 	Reagents []BalanceCompoundInfo // Array of reagent compound information.
 	Products []BalanceCompoundInfo // Array of product compound information.
+	Redox    *RedoxResponse        // Half-reactions and electron count, set in RedoxMode
+
+	Dimension    int      // Dimension of the solution space; above 1 the equation has several independent balancings
+	Alternatives []string // Minimal integer solutions spanning the solution space, set when no single balancing was chosen
+
+	Explanation *BalanceExplanation // Composition matrix, row reduction and scaling, set when options.Explain is true
+
+	Stoichiometry []StoichiometryRow // Amounts of every species for options.Amount, set when it is given
+	Yield         *YieldResponse     // Limiting reagent, excesses and yields, set when options.Available is given
+	MolarVolume   string             // Molar volume of gases at options.Gas, set with Stoichiometry or Yield
 }
 
 // fillCompoundInfo retrieves compound information from the data store and converts it to a slice of BalanceCompoundInfo structs.
+// Compounds are looked up by their canonical formula, so any spelling of a formula finds its record.
 //
 // Args:
 //
@@ -38,7 +74,14 @@ type BalanceResponse struct {
 //	Returns an empty slice if no compounds are found or an error occurs.
 //	error: An error object if there's an issue during retrieval from the data store.
 func (service BalanceService) fillCompoundInfo(formulas []string) ([]BalanceCompoundInfo, error) {
-	compounds, err := service.Store.GetCompounds(formulas)
+	canonical := make([]string, len(formulas))
+	for i, formula := range formulas {
+		var err error
+		if canonical[i], err = service.Canonicalize(formula); err != nil {
+			return nil, err
+		}
+	}
+	compounds, err := service.Store.GetCompounds(canonical)
 	if err != nil {
 		return nil, err
 	}
@@ -59,47 +102,130 @@ func (service BalanceService) fillCompoundInfo(formulas []string) ([]BalanceComp
 // GetResponse balances a chemical equation represented as a string and returns the balanced equation.
 //
 // It processes the input `requestedData`, which is expected to be in the form of
-// "reagents => products", where both reagents and products are separated by a "+" sign.
-// The function solves the system of linear equations formed by the stoichiometry of the chemical compounds
-// involved and returns the balanced equation as a string in the form of "reactantSide => productSide",
+// "reagents = products", where both reagents and products are separated by a "+" sign.
+// The equation is balanced by the service's Balancer, which solves the system of linear equations
+// formed by the stoichiometry of the chemical compounds involved.
+// The balanced equation is returned as a string in the form of "reactantSide = productSide",
 // along with additional information about reagents and products.
 //
+// When the equation has several independent balancings `Result` stays empty, `Dimension` reports
+// the dimension of the solution space and `Alternatives` lists a basis of minimal integer solutions,
+// each written as a reaction of its own. Pinning coefficients through `options.Pins` selects a
+// single balancing.
+//
+// With `options.Amount` the response also holds the amounts of every species that correspond to
+// that amount of `options.Given` in the balanced equation, in `Stoichiometry`. With `options.Available`
+// it holds in `Yield` the limiting reagent, the excess of the other reagents and the theoretical
+// yield of every product, and the percent yield of the products listed in `options.Actual`. Gas
+// volumes are those of an ideal gas at `options.Gas`.
+//
+// With `options.Explain` the equation is balanced by the NativeBalancer, whatever backend the service
+// is configured with, and `Explanation` holds the composition matrix, every row-reduction step, the
+// free variable and the scaling to integers.
+//
+// In RedoxMode the equation is balanced by the half-reaction method instead and `Redox` describes
+// the oxidation-state changes, both half-reactions and the number of electrons transferred. A net
+// ionic equation may then gain H2O, H^+ or OH^- from the medium. A molecular equation such as
+// "Cu + HNO3 = Cu(NO3)2 + NO + H2O" is balanced as written, its salts and strong acids split into
+// ions for the halves; it must already contain the water and acid the halves need.
+//
 // Arguments:
 //
-//	requestedData (string): The chemical equation to be balanced, formatted as "reagents => products".
+//	requestedData (string): The chemical equation to be balanced, formatted as "reagents = products".
+//	options (BalanceOptions): The balancing mode and its parameters.
 //
 // Returns:
 //
 //	BalanceResponse: A struct containing the balanced equation in `Result`, and additional compound information
 //	in `Reagents` and `Products`.
-//	error: An error, if any, that occurred during the balancing process.
+//	error: An error, if any, that occurred during the balancing process. Equations that cannot be
+//	balanced are reported as a *BalanceError.
 //
 // Example:
 //
-//	requestedData := "H2 + O2 => H2O"
-//	response, err := service.GetResponse(requestedData)
+//	requestedData := "H2 + O2 = H2O"
+//	response, err := service.GetResponse(requestedData, BalanceOptions{})
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
-//	fmt.Println(response.Result)
-func (service BalanceService) GetResponse(requestedData string) (BalanceResponse, error) {
-	var response BalanceResponse
-	copy := requestedData
-	cmd := exec.Command("python3", "balance.py", copy)
+//	fmt.Println(response.Result) // 2 H2 + O2 = 2 H2O
+func (service BalanceService) GetResponse(requestedData string, options BalanceOptions) (BalanceResponse, error) {
+	response := BalanceResponse{Reaction: requestedData}
 
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-	_ = cmd.Run()
+	equation, err := ParseEquation(requestedData)
+	if err != nil {
+		return response, err
+	}
 
-	response.Result = stdout.String()
+	var coefficients []int
+	switch options.Mode {
+	case "", AlgebraicMode:
+		equation.Pins, err = equation.ParsePins(options.Pins)
+		if err != nil {
+			return response, err
+		}
+		balancer := service.Balancer
+		if balancer == nil || options.Explain {
+			balancer = NativeBalancer{ChemicalService: service.ChemicalService, Explain: options.Explain}
+		}
+		solution, err := balancer.Balance(equation)
+		if err != nil {
+			return response, err
+		}
+		response.Dimension = solution.Dimension
+		response.Explanation = solution.Explanation
+		coefficients = solution.Coefficients
+		if coefficients != nil {
+			response.Result = equation.Format(coefficients)
+		}
+		for _, vector := range solution.Basis {
+			response.Alternatives = append(response.Alternatives, equation.FormatSigned(vector))
+		}
+	case RedoxMode:
+		balancer := RedoxBalancer{ChemicalService: service.ChemicalService, Medium: options.Medium}
+		balanced, balancedCoefficients, redox, err := balancer.Balance(equation)
+		if err != nil {
+			return response, err
+		}
+		equation, coefficients = balanced, balancedCoefficients
+		response.Result = balanced.Reaction
+		response.Redox = &redox
+	default:
+		return response, inputErrorf("unknown balance mode %q", options.Mode)
+	}
 
-	sides := strings.Split(requestedData, "=")
-	reagents := strings.Split(strings.TrimSpace(sides[0]), "+")
-	products := strings.Split(strings.TrimSpace(sides[1]), "+")
+	response.Reagents, err = service.fillCompoundInfo(equation.Reagents)
+	if err != nil {
+		return response, err
+	}
+	response.Products, err = service.fillCompoundInfo(equation.Products)
+	if err != nil {
+		return response, err
+	}
 
-	response.Reagents, _ = service.fillCompoundInfo(reagents)
-	response.Products, _ = service.fillCompoundInfo(products)
-	response.Reaction = requestedData
+	if options.Amount.Value == 0 && strings.TrimSpace(options.Available) == "" {
+		return response, nil
+	}
+	if coefficients == nil {
+		return response, inputErrorf("stoichiometry needs a single balancing, pin coefficients to choose one")
+	}
+	molarVolume, err := options.Gas.MolarVolume()
+	if err != nil {
+		return response, err
+	}
+	response.MolarVolume = units.Join(formatQuantity(options.Precision, molarVolume.Value, molarVolume.Figures), units.LitrePerMole)
+	if options.Amount.Value != 0 {
+		response.Stoichiometry, err = service.stoichiometry(equation, coefficients, molarVolume, options)
+		if err != nil {
+			return response, err
+		}
+	}
+	if strings.TrimSpace(options.Available) != "" {
+		response.Yield, err = service.yield(equation, coefficients, molarVolume, options)
+		if err != nil {
+			return response, err
+		}
+	}
 
 	return response, nil
 }
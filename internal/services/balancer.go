@@ -0,0 +1,484 @@
+package services
+
+import (
+	"ChemistryPR/internal/config"
+	"ChemistryPR/internal/models"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrMalformedEquation is reported when the equation text cannot be split
+	// into reagents and products.
+	ErrMalformedEquation = errors.New("malformed equation")
+	// ErrUnbalanceable is reported when no set of positive coefficients
+	// conserves every element.
+	ErrUnbalanceable = errors.New("equation cannot be balanced")
+	// ErrAmbiguousBalance is reported when the equation admits several
+	// independent balancings and the pins do not choose a single one.
+	ErrAmbiguousBalance = errors.New("equation has several independent balancings")
+	// ErrBalancerFailed is reported when an out-of-process backend could not
+	// be run or produced an invalid answer.
+	ErrBalancerFailed = errors.New("balancer backend failed")
+)
+
+// Balancer finds the coefficients of a chemical equation.
+//
+// Balance returns a Solution with one positive integer coefficient per
+// species, reagents first, in the order of Equation.Species. An equation with
+// several independent balancings and too few pins has no Coefficients and
+// reports a Basis instead. Failures are reported as a *BalanceError.
+type Balancer interface {
+	Balance(equation Equation) (Solution, error)
+}
+
+// Solution is the outcome of balancing an equation.
+type Solution struct {
+	Coefficients []int               // The balancing, nil when the equation is ambiguous
+	Dimension    int                 // Dimension of the solution space of the unpinned equation
+	Basis        [][]int             // Minimal integer solutions spanning the solution space when it is ambiguous
+	Explanation  *BalanceExplanation // Step-by-step working, only from backends asked to explain
+}
+
+// NewBalancer creates the backend selected in the configuration. The
+// chemical service is used by the native backend to parse formulas.
+func NewBalancer(cfg config.Balancer, chemical ChemicalService) (Balancer, error) {
+	switch cfg.Backend {
+	case "", "native":
+		return NativeBalancer{ChemicalService: chemical}, nil
+	case "python":
+		return ExternalBalancer{Command: cfg.Interpreter, Args: []string{cfg.Script}, Timeout: cfg.Timeout}, nil
+	case "external":
+		if cfg.Command == "" {
+			return nil, errors.New("external balancer requires a command")
+		}
+		return ExternalBalancer{Command: cfg.Command, Args: cfg.Args, Timeout: cfg.Timeout}, nil
+	default:
+		return nil, fmt.Errorf("unknown balancer backend %q", cfg.Backend)
+	}
+}
+
+// BalanceError describes why an equation could not be balanced. Reason is
+// one of the Err* values above and can be matched with errors.Is.
+type BalanceError struct {
+	Reaction string // The equation as it was requested
+	Reason   error  // The class of the failure
+	Detail   string // Human readable explanation
+}
+
+func (err *BalanceError) Error() string {
+	if err.Detail == "" {
+		return fmt.Sprintf("%s: %v", err.Reaction, err.Reason)
+	}
+	return fmt.Sprintf("%s: %v: %s", err.Reaction, err.Reason, err.Detail)
+}
+
+func (err *BalanceError) Unwrap() error {
+	return err.Reason
+}
+
+// equationArrows lists the separators accepted between reagents and
+// products, longest first so that "=>" is not mistaken for "=".
+var equationArrows = []string{"=>", "->", "→", "="}
+
+// Equation is a chemical equation split into its species.
+type Equation struct {
+	Reaction string         // The equation as it was requested
+	Reagents []string       // Formulas on the left-hand side
+	Products []string       // Formulas on the right-hand side
+	Pins     map[string]int // Coefficients fixed by the user, keyed by species as written
+}
+
+// ParseEquation splits an equation of the form "H2 + O2 = H2O" into reagents
+// and products. The sides may be separated by "=", "=>", "->" or "→" and the
+// species on each side by "+". When the equation contains a "+" surrounded
+// by whitespace only such pluses separate species, so ionic equations like
+// "Fe^3+ + e- = Fe^2+" keep their charges.
+//
+// Species written as SMILES, such as "smiles:C=C", run up to the next
+// whitespace and are taken as they are, so the "=" and "+" they contain
+// split nothing.
+func ParseEquation(reaction string) (Equation, error) {
+	equation := Equation{Reaction: reaction}
+
+	smiles := smilesPattern.FindAllString(reaction, -1)
+	masked := smilesPattern.ReplaceAllLiteralString(reaction, smilesPlaceholder)
+
+	var sides []string
+	for _, arrow := range equationArrows {
+		if strings.Contains(masked, arrow) {
+			sides = strings.Split(masked, arrow)
+			break
+		}
+	}
+	if len(sides) != 2 {
+		return equation, &BalanceError{Reaction: reaction, Reason: ErrMalformedEquation,
+			Detail: "expected exactly one separator between reagents and products"}
+	}
+
+	spaced := spacedPlusPattern.MatchString(masked)
+	var err error
+	if equation.Reagents, err = splitSpecies(reaction, sides[0], spaced); err != nil {
+		return equation, err
+	}
+	if equation.Products, err = splitSpecies(reaction, sides[1], spaced); err != nil {
+		return equation, err
+	}
+	for _, species := range [][]string{equation.Reagents, equation.Products} {
+		for i := range species {
+			for strings.Contains(species[i], smilesPlaceholder) {
+				species[i] = strings.Replace(species[i], smilesPlaceholder, smiles[0], 1)
+				smiles = smiles[1:]
+			}
+		}
+	}
+	return equation, nil
+}
+
+// smilesPattern matches a species written as SMILES in an equation.
+var smilesPattern = regexp.MustCompile(regexp.QuoteMeta(SMILESPrefix) + `\S+`)
+
+// smilesPlaceholder stands for a SMILES species while an equation is split.
+const smilesPlaceholder = "\x1a"
+
+// spacedPlusPattern matches a "+" that separates species in ionic equations.
+var spacedPlusPattern = regexp.MustCompile(`\s\+\s`)
+
+// splitSpecies splits one side of an equation on "+", or only on spaced
+// pluses when spaced is set, and trims the species.
+func splitSpecies(reaction, side string, spaced bool) ([]string, error) {
+	var species []string
+	if spaced {
+		species = spacedPlusPattern.Split(side, -1)
+	} else {
+		species = strings.Split(side, "+")
+	}
+	for i := range species {
+		species[i] = strings.TrimSpace(species[i])
+		if species[i] == "" {
+			return nil, &BalanceError{Reaction: reaction, Reason: ErrMalformedEquation,
+				Detail: "empty species"}
+		}
+	}
+	return species, nil
+}
+
+// ParsePins reads pinned coefficients written as "H2O=2, O2=1" and checks
+// that every pinned species is part of the equation. The last "=" of a pin
+// separates the coefficient, so SMILES species can be pinned too.
+func (equation Equation) ParsePins(text string) (map[string]int, error) {
+	pins := make(map[string]int)
+	if strings.TrimSpace(text) == "" {
+		return pins, nil
+	}
+
+	species := make(map[string]bool)
+	for _, formula := range equation.Species() {
+		species[formula] = true
+	}
+	for _, item := range strings.Split(text, ",") {
+		separator := strings.LastIndex(item, "=")
+		formula, value, ok := strings.TrimSpace(item[:max(separator, 0)]), item[separator+1:], separator >= 0
+		if !ok || !species[formula] {
+			return nil, &BalanceError{Reaction: equation.Reaction, Reason: ErrMalformedEquation,
+				Detail: fmt.Sprintf("pin %q does not name a species of the equation", strings.TrimSpace(item))}
+		}
+		coefficient, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || coefficient <= 0 {
+			return nil, &BalanceError{Reaction: equation.Reaction, Reason: ErrMalformedEquation,
+				Detail: fmt.Sprintf("pinned coefficient of %s must be a positive integer", formula)}
+		}
+		pins[formula] = coefficient
+	}
+	return pins, nil
+}
+
+// Species returns reagents followed by products.
+func (equation Equation) Species() []string {
+	species := make([]string, 0, len(equation.Reagents)+len(equation.Products))
+	species = append(species, equation.Reagents...)
+	return append(species, equation.Products...)
+}
+
+// Format renders the equation with the given coefficients, omitting
+// coefficients equal to one.
+func (equation Equation) Format(coefficients []int) string {
+	format := func(species []string, offset int) string {
+		terms := make([]string, len(species))
+		for i, formula := range species {
+			if coefficients[offset+i] == 1 {
+				terms[i] = formula
+			} else {
+				terms[i] = fmt.Sprintf("%d %s", coefficients[offset+i], formula)
+			}
+		}
+		return strings.Join(terms, " + ")
+	}
+	return format(equation.Reagents, 0) + " = " + format(equation.Products, len(equation.Reagents))
+}
+
+// FormatSigned renders one vector of the solution space. Species with a
+// negative entry move to the other side and species with a zero entry are
+// left out, so every basis vector reads as a reaction of its own.
+func (equation Equation) FormatSigned(vector []int) string {
+	signed := Equation{}
+	var reagents, products []int
+	for i, formula := range equation.Species() {
+		value := vector[i]
+		if i >= len(equation.Reagents) {
+			value = -value
+		}
+		switch {
+		case value > 0:
+			signed.Reagents = append(signed.Reagents, formula)
+			reagents = append(reagents, value)
+		case value < 0:
+			signed.Products = append(signed.Products, formula)
+			products = append(products, -value)
+		}
+	}
+	return signed.Format(append(reagents, products...))
+}
+
+// chargeRow labels the charge-conservation row of the composition matrix.
+const chargeRow = "charge"
+
+// NativeBalancer balances equations in pure Go. It builds the
+// element-by-species composition matrix and finds its integer nullspace with
+// exact rational arithmetic. When any species is charged the matrix gains a
+// row that conserves charge.
+type NativeBalancer struct {
+	ChemicalService
+	Explain bool // Record the working in Solution.Explanation
+}
+
+// Balance returns the smallest positive integer coefficients, reagents
+// first, that conserve every element of the equation.
+//
+// When the nullspace has more than one dimension the Solution carries its
+// basis, each vector scaled to the smallest integers, and no coefficients.
+// Pinned coefficients are added as constraints; they fix the values of the
+// named species, and if the solution they determine is fractional every
+// coefficient is scaled by the smallest factor that makes it integer.
+//
+// Returns a *BalanceError when the equation has no positive solution or
+// the pins contradict each other or leave the answer undetermined.
+func (balancer NativeBalancer) Balance(equation Equation) (Solution, error) {
+	matrix, elements, err := balancer.compositionMatrix(equation)
+	if err != nil {
+		return Solution{}, err
+	}
+	if err := checkElementSides(equation, matrix, elements); err != nil {
+		return Solution{}, err
+	}
+
+	var pinned ratMatrix
+	if len(equation.Pins) > 0 {
+		pinned = matrix.augmented()
+	}
+
+	var trace rowTracer
+	var explainer *explainer
+	if balancer.Explain {
+		explainer = newExplainer(equation, elements, matrix)
+		trace = explainer.trace
+	}
+	basis, frees := matrix.nullspace(trace)
+	solution := Solution{Dimension: len(basis)}
+	if explainer != nil {
+		explainer.nullspace(basis, frees)
+		solution.Explanation = explainer.explanation
+	}
+	if len(basis) == 0 {
+		return solution, &BalanceError{Reaction: equation.Reaction, Reason: ErrUnbalanceable,
+			Detail: "only the trivial solution conserves every element"}
+	}
+
+	if len(equation.Pins) > 0 {
+		solution.Coefficients, err = pinnedCoefficients(equation, pinned)
+		return solution, err
+	}
+
+	if len(basis) > 1 {
+		solution.Basis = make([][]int, len(basis))
+		for i, vector := range basis {
+			solution.Basis[i], err = integerCoefficients(equation, integerVector(vector))
+			if err != nil {
+				return solution, err
+			}
+		}
+		return solution, nil
+	}
+
+	vector := integerVector(basis[0])
+	for _, value := range vector {
+		if value.Sign() != 0 {
+			if value.Sign() < 0 {
+				for _, v := range vector {
+					v.Neg(v)
+				}
+			}
+			break
+		}
+	}
+	solution.Coefficients, err = positiveCoefficients(equation, vector)
+	if solution.Explanation != nil {
+		solution.Explanation.Coefficients = solution.Coefficients
+	}
+	return solution, err
+}
+
+// pinnedCoefficients solves the composition matrix, given with an extra zero
+// right-hand-side column, together with one row per pinned species.
+func pinnedCoefficients(equation Equation, augmented ratMatrix) ([]int, error) {
+	species := equation.Species()
+	variables := len(species)
+	for i, formula := range species {
+		value, ok := equation.Pins[formula]
+		if !ok {
+			continue
+		}
+		row := make([]*big.Rat, variables+1)
+		for j := range row {
+			row[j] = new(big.Rat)
+		}
+		row[i].SetInt64(1)
+		row[variables].SetInt64(int64(value))
+		augmented = append(augmented, row)
+	}
+
+	values, consistent := augmented.solveAugmented()
+	if !consistent {
+		return nil, &BalanceError{Reaction: equation.Reaction, Reason: ErrUnbalanceable,
+			Detail: "the pinned coefficients contradict each other"}
+	}
+	if values == nil {
+		return nil, &BalanceError{Reaction: equation.Reaction, Reason: ErrAmbiguousBalance,
+			Detail: "pin more coefficients to choose a single balancing"}
+	}
+	return positiveCoefficients(equation, scaleToIntegers(values))
+}
+
+// compositionMatrix builds the matrix with one row per element and one
+// column per species. Reagent counts are positive and product counts are
+// negative, so a balanced equation is a vector in the nullspace. Charges
+// form an extra row labelled chargeRow.
+func (balancer NativeBalancer) compositionMatrix(equation Equation) (ratMatrix, []string, error) {
+	species := equation.Species()
+	counts := make([]map[string]int, len(species))
+	seen := make(map[string]bool)
+	charged := false
+	for i, formula := range species {
+		compound, err := balancer.ParseCompound(formula)
+		if err != nil {
+			return nil, nil, err
+		}
+		counts[i] = conservedCounts(compound)
+		for symbol := range counts[i] {
+			seen[symbol] = true
+		}
+		if compound.Charge != 0 {
+			counts[i][chargeRow] = compound.Charge
+			charged = true
+		}
+	}
+
+	elements := make([]string, 0, len(seen))
+	for symbol := range seen {
+		elements = append(elements, symbol)
+	}
+	sort.Strings(elements)
+	if charged {
+		elements = append(elements, chargeRow)
+	}
+
+	matrix := newRatMatrix(len(elements), len(species))
+	for row, symbol := range elements {
+		for col := range species {
+			count := int64(counts[col][symbol])
+			if col >= len(equation.Reagents) {
+				count = -count
+			}
+			matrix[row][col].SetInt64(count)
+		}
+	}
+	return matrix, elements, nil
+}
+
+// conservedCounts returns the atom counts a reaction conserves. Labelled
+// atoms are counted by nuclide, e.g. "2H", apart from the unlabelled atoms
+// of their element, so D2O does not balance against H2O.
+func conservedCounts(compound models.Compound) map[string]int {
+	counts := make(map[string]int, len(compound.Data)+len(compound.Isotopes)+1)
+	for symbol, count := range compound.Data {
+		counts[symbol] = count
+	}
+	for nuclide, count := range compound.Isotopes {
+		counts[nuclide.Symbol] -= count
+		counts[nuclide.String()] = count
+	}
+	for symbol, count := range counts {
+		if count == 0 {
+			delete(counts, symbol)
+		}
+	}
+	return counts
+}
+
+// checkElementSides reports elements that occur on only one side of the
+// equation, which is the most common reason for an unbalanceable input.
+func checkElementSides(equation Equation, matrix ratMatrix, elements []string) error {
+	for row, symbol := range elements {
+		if symbol == chargeRow {
+			continue
+		}
+		left, right := false, false
+		for col, value := range matrix[row] {
+			if value.Sign() == 0 {
+				continue
+			}
+			if col < len(equation.Reagents) {
+				left = true
+			} else {
+				right = true
+			}
+		}
+		if left != right {
+			return &BalanceError{Reaction: equation.Reaction, Reason: ErrUnbalanceable,
+				Detail: fmt.Sprintf("element %s appears on one side only", symbol)}
+		}
+	}
+	return nil
+}
+
+// positiveCoefficients converts an integer solution to coefficients and
+// checks that every species takes part with a positive coefficient.
+func positiveCoefficients(equation Equation, vector []*big.Int) ([]int, error) {
+	species := equation.Species()
+	for i, value := range vector {
+		if value.Sign() <= 0 {
+			return nil, &BalanceError{Reaction: equation.Reaction, Reason: ErrUnbalanceable,
+				Detail: fmt.Sprintf("%s cannot take part in the reaction as written", species[i])}
+		}
+	}
+	return integerCoefficients(equation, vector)
+}
+
+// integerCoefficients converts an integer vector to ints, rejecting values
+// that do not fit.
+func integerCoefficients(equation Equation, vector []*big.Int) ([]int, error) {
+	coefficients := make([]int, len(vector))
+	for i, value := range vector {
+		if !value.IsInt64() || value.Int64() > int64(^uint(0)>>1) || value.Int64() < -int64(^uint(0)>>1) {
+			return nil, &BalanceError{Reaction: equation.Reaction, Reason: ErrUnbalanceable,
+				Detail: "coefficients are too large"}
+		}
+		coefficients[i] = int(value.Int64())
+	}
+	return coefficients, nil
+}
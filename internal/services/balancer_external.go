@@ -0,0 +1,118 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ExternalBalancer delegates balancing to another program.
+//
+// The program is started once per equation and speaks JSON over its standard
+// streams. It receives a single object on stdin:
+//
+//	{"reagents": ["H2", "O2"], "products": ["H2O"], "pins": {"O2": 2}}
+//
+// Species are passed as written, including any charge suffix such as "Fe^2+".
+// "pins" is present only when the user fixed some coefficients; a program
+// that cannot honour it must answer with an error.
+//
+// The program must write a single object to stdout, either the coefficients
+// in the order reagents then products:
+//
+//	{"coefficients": [2, 1, 2]}
+//
+// or, for an equation with several independent balancings, a basis of the
+// solution space in the same order:
+//
+//	{"basis": [[2, 1, 2, 0], [1, 1, 0, 1]]}
+//
+// or the reason the equation cannot be balanced:
+//
+//	{"error": "element H appears on one side only"}
+//
+// A non-zero exit status is treated as a failure of the backend itself and
+// its stderr is included in the returned error. The "python" backend is this
+// protocol spoken by balance.py, which understands charges, brackets and
+// hydrate dots but answers with an error for isotopes.
+type ExternalBalancer struct {
+	Command string        // Program to run
+	Args    []string      // Arguments passed before any input is written
+	Timeout time.Duration // Upper bound for a single run, zero means no limit
+}
+
+// externalRequest is the message written to the program's stdin.
+type externalRequest struct {
+	Reagents []string       `json:"reagents"`
+	Products []string       `json:"products"`
+	Pins     map[string]int `json:"pins,omitempty"`
+}
+
+// externalResponse is the message read from the program's stdout.
+type externalResponse struct {
+	Coefficients []int   `json:"coefficients"`
+	Basis        [][]int `json:"basis"`
+	Error        string  `json:"error"`
+}
+
+// Balance runs the external program for the equation and validates its
+// answer.
+func (balancer ExternalBalancer) Balance(equation Equation) (Solution, error) {
+	failure := func(format string, args ...any) error {
+		return &BalanceError{Reaction: equation.Reaction, Reason: ErrBalancerFailed,
+			Detail: fmt.Sprintf(format, args...)}
+	}
+
+	input, err := json.Marshal(externalRequest{Reagents: equation.Reagents, Products: equation.Products, Pins: equation.Pins})
+	if err != nil {
+		return Solution{}, failure("encoding request: %v", err)
+	}
+
+	ctx := context.Background()
+	if balancer.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, balancer.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, balancer.Command, balancer.Args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return Solution{}, failure("%s: %v: %s", balancer.Command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var response externalResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return Solution{}, failure("decoding response: %v", err)
+	}
+	if response.Error != "" {
+		return Solution{}, &BalanceError{Reaction: equation.Reaction, Reason: ErrUnbalanceable, Detail: response.Error}
+	}
+
+	species := len(equation.Reagents) + len(equation.Products)
+	if len(response.Basis) > 1 {
+		for _, vector := range response.Basis {
+			if len(vector) != species {
+				return Solution{}, failure("expected basis vectors of %d entries, got %v", species, vector)
+			}
+		}
+		return Solution{Dimension: len(response.Basis), Basis: response.Basis}, nil
+	}
+
+	if len(response.Coefficients) != species {
+		return Solution{}, failure("expected %d coefficients, got %d", species, len(response.Coefficients))
+	}
+	for _, coefficient := range response.Coefficients {
+		if coefficient <= 0 {
+			return Solution{}, failure("coefficients must be positive, got %v", response.Coefficients)
+		}
+	}
+	return Solution{Coefficients: response.Coefficients, Dimension: 1}, nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestNativeBalancer(t *testing.T) {
+	tests := []struct {
+		name         string
+		reaction     string
+		pins         string
+		coefficients []int
+		dimension    int
+		basis        [][]int
+		err          error
+	}{
+		{name: "simple", reaction: "H2 + O2 = H2O", coefficients: []int{2, 1, 2}, dimension: 1},
+		{name: "combustion", reaction: "C3H8 + O2 = CO2 + H2O", coefficients: []int{1, 5, 3, 4}, dimension: 1},
+		{name: "groups", reaction: "Ca(OH)2 + H3PO4 = Ca3(PO4)2 + H2O", coefficients: []int{3, 2, 1, 6}, dimension: 1},
+		{name: "arrow", reaction: "Fe + O2 -> Fe2O3", coefficients: []int{4, 3, 2}, dimension: 1},
+		{name: "ionic", reaction: "Fe^3+ + e- = Fe^2+", coefficients: []int{1, 1, 1}, dimension: 1},
+		{name: "ionic redox", reaction: "MnO4^- + Fe^2+ + H^+ = Mn^2+ + Fe^3+ + H2O", coefficients: []int{1, 5, 8, 1, 5, 4}, dimension: 1},
+		{name: "smiles", reaction: "smiles:C=C + O2 = CO2 + H2O", coefficients: []int{1, 3, 2, 2}, dimension: 1},
+		{name: "several balancings", reaction: "C + O2 = CO + CO2", dimension: 2, basis: [][]int{{2, 1, 2, 0}, {1, 1, 0, 1}}},
+		{name: "pinned", reaction: "C + O2 = CO + CO2", pins: "CO=2, CO2=1", coefficients: []int{3, 2, 2, 1}, dimension: 2},
+		{name: "pinned fraction", reaction: "H2 + O2 = H2O", pins: "O2=1", coefficients: []int{2, 1, 2}, dimension: 1},
+		{name: "contradicting pins", reaction: "H2 + O2 = H2O", pins: "H2=1, O2=1", err: ErrUnbalanceable},
+		{name: "element on one side", reaction: "H2 + O2 = H2O + N2", err: ErrUnbalanceable},
+		{name: "no positive solution", reaction: "H2O = H2O2", err: ErrUnbalanceable},
+	}
+
+	balancer := NativeBalancer{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			equation, err := ParseEquation(test.reaction)
+			if err != nil {
+				t.Fatalf("ParseEquation(%q): %v", test.reaction, err)
+			}
+			if equation.Pins, err = equation.ParsePins(test.pins); err != nil {
+				t.Fatalf("ParsePins(%q): %v", test.pins, err)
+			}
+
+			solution, err := balancer.Balance(equation)
+			if test.err != nil {
+				var balanceError *BalanceError
+				if !errors.Is(err, test.err) || !errors.As(err, &balanceError) {
+					t.Fatalf("Balance(%q) error = %v, want a *BalanceError wrapping %v", test.reaction, err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Balance(%q): %v", test.reaction, err)
+			}
+			if !slices.Equal(solution.Coefficients, test.coefficients) {
+				t.Errorf("Balance(%q) coefficients = %v, want %v", test.reaction, solution.Coefficients, test.coefficients)
+			}
+			if solution.Dimension != test.dimension {
+				t.Errorf("Balance(%q) dimension = %d, want %d", test.reaction, solution.Dimension, test.dimension)
+			}
+			if !slices.EqualFunc(solution.Basis, test.basis, slices.Equal[[]int]) {
+				t.Errorf("Balance(%q) basis = %v, want %v", test.reaction, solution.Basis, test.basis)
+			}
+		})
+	}
+}
+
+func TestParseEquation(t *testing.T) {
+	tests := []struct {
+		reaction string
+		reagents []string
+		products []string
+		err      error
+	}{
+		{reaction: "H2+O2=H2O", reagents: []string{"H2", "O2"}, products: []string{"H2O"}},
+		{reaction: "H2 + O2 => H2O", reagents: []string{"H2", "O2"}, products: []string{"H2O"}},
+		{reaction: "Fe^3+ + e- → Fe^2+", reagents: []string{"Fe^3+", "e-"}, products: []string{"Fe^2+"}},
+		{reaction: "smiles:C=C + H2 => smiles:CC", reagents: []string{"smiles:C=C", "H2"}, products: []string{"smiles:CC"}},
+		{reaction: "H2 + O2", err: ErrMalformedEquation},
+		{reaction: "H2 + = H2O", err: ErrMalformedEquation},
+	}
+
+	for _, test := range tests {
+		equation, err := ParseEquation(test.reaction)
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("ParseEquation(%q) error = %v, want %v", test.reaction, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseEquation(%q): %v", test.reaction, err)
+			continue
+		}
+		if !slices.Equal(equation.Reagents, test.reagents) || !slices.Equal(equation.Products, test.products) {
+			t.Errorf("ParseEquation(%q) = %q = %q, want %q = %q", test.reaction,
+				equation.Reagents, equation.Products, test.reagents, test.products)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"ChemistryPR/internal/models"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CanonicalFormula writes the element counts of compound in a canonical
+// order, so that every spelling of a compound gives the same string.
+//
+// Compounds containing carbon follow the Hill system: carbon first,
+// hydrogen second, then the other elements alphabetically. Compounds
+// without carbon list all elements, hydrogen included, alphabetically, as
+// Hill indexes do for inorganics. Counts of one are omitted, labelled atoms
+// follow the unlabelled atoms of their element as "[13C]" by increasing
+// mass number, and a charge is appended as "^2-". Groups, hydrate dots and
+// abbreviations are all flattened: "OH2", "H2O" and "H2O " give "H2O",
+// "CuSO4·5H2O" gives "CuH10O9S".
+func CanonicalFormula(compound models.Compound) string {
+	symbols := make([]string, 0, len(compound.Data))
+	for symbol := range compound.Data {
+		symbols = append(symbols, symbol)
+	}
+	_, organic := compound.Data["C"]
+	rank := func(symbol string) int {
+		switch {
+		case organic && symbol == "C":
+			return 0
+		case organic && symbol == "H":
+			return 1
+		}
+		return 2
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		if ri, rj := rank(symbols[i]), rank(symbols[j]); ri != rj {
+			return ri < rj
+		}
+		return symbols[i] < symbols[j]
+	})
+
+	var formula strings.Builder
+	write := func(text string, count int) {
+		formula.WriteString(text)
+		if count != 1 {
+			fmt.Fprint(&formula, count)
+		}
+	}
+	for _, symbol := range symbols {
+		var labels []models.Nuclide
+		unlabelled := compound.Data[symbol]
+		for nuclide, count := range compound.Isotopes {
+			if nuclide.Symbol == symbol {
+				labels = append(labels, nuclide)
+				unlabelled -= count
+			}
+		}
+		sort.Slice(labels, func(i, j int) bool { return labels[i].MassNumber < labels[j].MassNumber })
+
+		if unlabelled > 0 {
+			write(symbol, unlabelled)
+		}
+		for _, nuclide := range labels {
+			write("["+nuclide.String()+"]", compound.Isotopes[nuclide])
+		}
+	}
+
+	switch {
+	case compound.Charge == 1:
+		formula.WriteString("^+")
+	case compound.Charge == -1:
+		formula.WriteString("^-")
+	case compound.Charge > 0:
+		fmt.Fprintf(&formula, "^%d+", compound.Charge)
+	case compound.Charge < 0:
+		fmt.Fprintf(&formula, "^%d-", -compound.Charge)
+	}
+	return formula.String()
+}
+
+// Canonicalize parses formula and returns its CanonicalFormula.
+func (service ChemicalService) Canonicalize(formula string) (string, error) {
+	compound, err := service.ParseCompound(formula)
+	if err != nil {
+		return "", err
+	}
+	return CanonicalFormula(compound), nil
+}
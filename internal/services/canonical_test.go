@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		formula   string
+		canonical string
+	}{
+		// Hill order with carbon: C, H, then alphabetically.
+		{formula: "CH3COOH", canonical: "C2H4O2"},
+		{formula: "C2H5OH", canonical: "C2H6O"},
+		{formula: "CH3Cl", canonical: "CH3Cl"},
+		{formula: "NaHCO3", canonical: "CHNaO3"},
+		{formula: "Fe(CN)6^3-", canonical: "C6FeN6^3-"},
+		{formula: "CCl4", canonical: "CCl4"},
+		// Without carbon every element, hydrogen included, is alphabetical.
+		{formula: "H2O", canonical: "H2O"},
+		{formula: "OH2", canonical: "H2O"},
+		{formula: "NH3", canonical: "H3N"},
+		{formula: "H2SO4", canonical: "H2O4S"},
+		{formula: "NaCl", canonical: "ClNa"},
+		{formula: "CuSO4·5H2O", canonical: "CuH10O9S"},
+		// Charges follow the counts.
+		{formula: "NH4+", canonical: "H4N^+"},
+		{formula: "SO4^2-", canonical: "O4S^2-"},
+		{formula: "OH-", canonical: "HO^-"},
+		{formula: "Fe^3+", canonical: "Fe^3+"},
+		{formula: "CH3COO-", canonical: "C2H3O2^-"},
+		// Labelled atoms follow the unlabelled ones of their element.
+		{formula: "[13C]H3CH3", canonical: "C[13C]H6"},
+		{formula: "D2O", canonical: "[2H]2O"},
+		{formula: "smiles:CCO", canonical: "C2H6O"},
+		{formula: " H2O(l) ", canonical: "H2O"},
+	}
+
+	service := ChemicalService{}
+	for _, test := range tests {
+		canonical, err := service.Canonicalize(test.formula)
+		if err != nil {
+			t.Errorf("Canonicalize(%q): %v", test.formula, err)
+			continue
+		}
+		if canonical != test.canonical {
+			t.Errorf("Canonicalize(%q) = %q, want %q", test.formula, canonical, test.canonical)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"ChemistryPR/internal/units"
+	"math/big"
+	"strings"
+)
+
+// CombustionService finds the formula of an organic compound from the
+// masses of the products of burning a weighed sample: carbon is collected
+// as CO2, hydrogen as H2O, nitrogen as N2 and sulfur as SO2, and oxygen is
+// what remains of the sample.
+type CombustionService struct {
+	ChemicalService
+}
+
+// CombustionInput holds the masses of a combustion analysis. Bare numbers
+// are taken to be grams.
+type CombustionInput struct {
+	SampleMass units.Quantity // Mass of the burnt sample
+	CO2        units.Quantity // Mass of carbon dioxide collected
+	H2O        units.Quantity // Mass of water collected
+	N2         units.Quantity // Mass of nitrogen collected, zero when the compound has none
+	SO2        units.Quantity // Mass of sulfur dioxide collected, zero when the compound has none
+}
+
+// CombustionResponse is the result of a combustion analysis: where every
+// element of the sample came from, and the empirical and molecular
+// formulas of its composition.
+type CombustionResponse struct {
+	EmpiricalFormulaResponse
+	SampleMass string
+	Products   []CombustionProductInfo // Products with a non-zero mass, in the order CO2, H2O, N2, SO2
+	OxygenMass string                  // Mass of oxygen in the sample, by difference; zero when the products account for the whole sample
+}
+
+// CombustionProductInfo is the share of one element in one combustion
+// product.
+type CombustionProductInfo struct {
+	Formula     string // Formula of the product, e.g. "CO2"
+	Mass        string // Mass of the product collected
+	MolarMass   string // Molar mass of the product
+	Symbol      string // Element of the sample the product carries
+	ElementMass string // Mass of that element in the product, and so in the sample
+}
+
+// combustionProducts maps every product to the element it carries.
+var combustionProducts = []struct {
+	formula string
+	symbol  string
+}{{"CO2", "C"}, {"H2O", "H"}, {"N2", "N"}, {"SO2", "S"}}
+
+// combustionTolerance is the share of the sample mass by which the
+// elements found in the products may miss it before the difference is
+// taken for oxygen, or, when they exceed it, for an error.
+const combustionTolerance = 0.005
+
+// GetResponse derives the composition of the sample from the masses of
+// its combustion products and passes it to the empirical formula
+// calculation, which finds the molecular formula too when
+// options.MolarMass is set.
+//
+// The share of an element in its product comes from the atomic weights of
+// the periodic table, computed as MolarMassService does. The sample mass
+// not accounted for by C, H, N and S is taken as oxygen. By default masses
+// and percentages keep the significant figures of the least precise mass
+// they come from.
+func (service CombustionService) GetResponse(input CombustionInput, options EmpiricalFormulaOptions) (CombustionResponse, error) {
+	precision := options.Precision
+	if err := precision.Validate(); err != nil {
+		return CombustionResponse{}, err
+	}
+	masses := []units.Quantity{input.SampleMass, input.CO2, input.H2O, input.N2, input.SO2}
+	for i, mass := range masses {
+		var err error
+		if masses[i], err = mass.Default(units.Gram).In(units.Gram); err != nil {
+			return CombustionResponse{}, err
+		}
+	}
+	sample := masses[0]
+	masses = masses[1:]
+	response := CombustionResponse{SampleMass: units.Join(precision.Format(sample.Value, sample.Figures), units.Gram)}
+	if sample.Value <= 0 {
+		return response, inputErrorf("sample mass must be positive, got %s", sample)
+	}
+
+	var shares []massShare
+	found := 0.0
+	foundPlaces := decimalPlaces(sample.Value, sample.Figures)
+	for i, product := range combustionProducts {
+		mass := masses[i]
+		if mass.Value < 0 {
+			return response, inputErrorf("mass of %s must not be negative, got %s", product.formula, mass)
+		}
+		if mass.Value == 0 {
+			continue
+		}
+		fraction, fractionFigures, molarMass, molarPlaces, err := service.massFraction(product.formula, product.symbol)
+		if err != nil {
+			return response, err
+		}
+		elementMass := mass.Value * fraction
+		elementFigures := min(mass.Figures, fractionFigures)
+		found += elementMass
+		foundPlaces = min(foundPlaces, decimalPlaces(elementMass, elementFigures))
+		percent := units.Quantity{Value: elementMass / sample.Value * 100, Figures: min(elementFigures, sample.Figures)}
+		shares = append(shares, massShare{symbol: product.symbol, percent: percent})
+		response.Products = append(response.Products, CombustionProductInfo{
+			Formula:     product.formula,
+			Mass:        units.Join(precision.Format(mass.Value, mass.Figures), units.Gram),
+			MolarMass:   units.Join(precision.FormatExact(molarMass, molarPlaces), units.GramPerMole),
+			Symbol:      product.symbol,
+			ElementMass: units.Join(precision.Format(elementMass, elementFigures), units.Gram),
+		})
+	}
+	if len(shares) == 0 {
+		return response, inputErrorf("no combustion products entered")
+	}
+
+	oxygen := sample.Value - found
+	oxygenFigures := significantPlaces(oxygen, foundPlaces)
+	switch {
+	case oxygen < -sample.Value*combustionTolerance:
+		return response, inputErrorf("the products hold %s of C, H, N and S, more than the %s sample",
+			units.Join(precision.Format(found, significantPlaces(found, foundPlaces)), units.Gram), response.SampleMass)
+	case oxygen > sample.Value*combustionTolerance:
+		percent := units.Quantity{Value: oxygen / sample.Value * 100, Figures: min(oxygenFigures, sample.Figures)}
+		shares = append(shares, massShare{symbol: "O", percent: percent})
+	default:
+		oxygen = 0
+	}
+	response.OxygenMass = units.Join(precision.Format(oxygen, oxygenFigures), units.Gram)
+
+	entries := make([]string, len(shares))
+	for i, share := range shares {
+		entries[i] = share.symbol + " " + Precision{}.Format(share.percent.Value, share.percent.Figures)
+	}
+	empirical, err := EmpiricalFormulaService{service.ChemicalService}.fromShares(strings.Join(entries, ", "), shares, options)
+	response.EmpiricalFormulaResponse = empirical
+	return response, err
+}
+
+// massFraction returns the mass fraction of the element symbol in the
+// compound formula with its significant figures, and the exact molar mass
+// of the compound with its decimal places.
+func (service CombustionService) massFraction(formula, symbol string) (float64, int, *big.Rat, int, error) {
+	compound, err := service.ParseCompound(formula)
+	if err != nil {
+		return 0, 0, nil, 0, err
+	}
+	elements, err := service.GetElements(compound)
+	if err != nil {
+		return 0, 0, nil, 0, err
+	}
+	molarMass, molarPlaces := compoundWeight(compound, elements, nil)
+	for _, element := range elements {
+		if element.Symbol != symbol {
+			continue
+		}
+		weight, weightPlaces := atomsWeight(element, compound.Data[symbol], nil, nil)
+		fraction, _ := new(big.Rat).Quo(weight, molarMass).Float64()
+		weightValue, _ := weight.Float64()
+		molarValue, _ := molarMass.Float64()
+		figures := min(significantPlaces(weightValue, weightPlaces), significantPlaces(molarValue, molarPlaces))
+		return fraction, figures, molarMass, molarPlaces, nil
+	}
+	return 0, 0, nil, 0, inputErrorf("%w %s in %s", ErrUnknownElement, symbol, formula)
+}
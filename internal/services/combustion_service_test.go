@@ -0,0 +1,85 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestCombustion(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     [5]string // Sample, CO2, H2O, N2 and SO2 masses
+		molarMass string
+		empirical string
+		molecular string
+		oxygen    string
+		products  int
+	}{
+		{
+			name: "hydrocarbon", input: [5]string{"1.000 g", "3.384 g", "0.6925 g"}, molarMass: "78.1",
+			empirical: "CH", molecular: "C6H6", oxygen: "0 g", products: 2,
+		},
+		{
+			name: "oxygen by difference", input: [5]string{"1.000 g", "1.911 g", "1.173 g"},
+			empirical: "C2H6O", oxygen: "0.347 g", products: 2,
+		},
+		{
+			name: "nitrogen", input: [5]string{"1.000", "1.173", "0.5999", "0.1866"}, molarMass: "75",
+			empirical: "C2H5NO2", molecular: "C2H5NO2", oxygen: "0.426 g", products: 3,
+		},
+		{
+			name: "sulfur", input: [5]string{"1.000 g", "1.417 g", "0.8699 g", "", "1.031 g"},
+			empirical: "C2H6S", oxygen: "0 g", products: 3,
+		},
+	}
+
+	service := CombustionService{testService(t)}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := CombustionInput{
+				SampleMass: parseQuantity(t, test.input[0]),
+				CO2:        parseQuantity(t, test.input[1]),
+				H2O:        parseQuantity(t, test.input[2]),
+				N2:         parseQuantity(t, test.input[3]),
+				SO2:        parseQuantity(t, test.input[4]),
+			}
+			response, err := service.GetResponse(input, EmpiricalFormulaOptions{MolarMass: parseQuantity(t, test.molarMass)})
+			if err != nil {
+				t.Fatalf("GetResponse: %v", err)
+			}
+			if response.Empirical != test.empirical || response.Molecular != test.molecular {
+				t.Errorf("GetResponse = %s, molecular %q; want %s, molecular %q", response.Empirical, response.Molecular, test.empirical, test.molecular)
+			}
+			if response.OxygenMass != test.oxygen {
+				t.Errorf("OxygenMass = %q, want %q", response.OxygenMass, test.oxygen)
+			}
+			if len(response.Products) != test.products {
+				t.Errorf("%d products, want %d", len(response.Products), test.products)
+			}
+		})
+	}
+}
+
+func TestCombustionErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input [5]string
+	}{
+		{name: "no sample mass", input: [5]string{"", "1 g", "1 g"}},
+		{name: "products heavier than the sample", input: [5]string{"0.100 g", "3.384 g", "0.6925 g"}},
+		{name: "no carbon dioxide or water", input: [5]string{"1.000 g"}},
+		{name: "negative mass", input: [5]string{"1.000 g", "-1 g", "1 g"}},
+		{name: "volume", input: [5]string{"1.000 g", "1 L", "1 g"}},
+	}
+
+	service := CombustionService{testService(t)}
+	for _, test := range tests {
+		input := CombustionInput{
+			SampleMass: parseQuantity(t, test.input[0]),
+			CO2:        parseQuantity(t, test.input[1]),
+			H2O:        parseQuantity(t, test.input[2]),
+		}
+		if _, err := service.GetResponse(input, EmpiricalFormulaOptions{}); err == nil {
+			t.Errorf("%s: GetResponse succeeded, want an error", test.name)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"ChemistryPR/internal/units"
+	"math"
+	"strings"
+)
+
+// ConversionService converts an amount of a compound between grams,
+// moles, formula units and atoms of its elements, using the molar mass
+// MolarMassService computes.
+type ConversionService struct {
+	ChemicalService
+}
+
+// ConversionUnit is the unit a converted quantity is given in.
+type ConversionUnit string
+
+const (
+	GramUnit     ConversionUnit = "g"         // Mass in grams
+	MoleUnit     ConversionUnit = "mol"       // Amount of substance in moles
+	ParticleUnit ConversionUnit = "particles" // Number of molecules or formula units
+	AtomUnit     ConversionUnit = "atoms"     // Number of atoms of one element, named by ConversionOptions.Element
+)
+
+// AvogadroConstant is the number of particles in a mole. It is exact by
+// definition and so does not limit significant figures.
+const AvogadroConstant = 6.02214076e23
+
+// ConversionOptions holds the parameters of a conversion besides the
+// formula and the quantity.
+type ConversionOptions struct {
+	Unit      ConversionUnit // Unit of a bare-number quantity, GramUnit when empty
+	Element   string         // Element whose atoms the quantity counts, for AtomUnit only
+	Precision Precision      // Rounding of the results
+}
+
+// ConversionResponse holds a quantity of a compound in every unit.
+type ConversionResponse struct {
+	Formula   string
+	MolarMass string               // Molar mass of the compound, e.g. "18.015 g/mol"
+	Grams     string               // Mass, e.g. "36.0 g"
+	Moles     string               // Amount, e.g. "2.00 mol"
+	Particles string               // Molecules or formula units, in scientific notation, e.g. "6.022e23"
+	Atoms     []ConversionAtomInfo // Atoms of every element of the compound
+}
+
+// ConversionAtomInfo is the number of atoms of one element in the
+// converted quantity.
+type ConversionAtomInfo struct {
+	Symbol string
+	Name   string // Name of the element
+	Count  string // Number of atoms, in scientific notation
+}
+
+// GetResponse converts quantity of the compound formula into grams,
+// moles, particles and atoms of every element. A mass or an amount in any
+// unit is converted from its unit; a bare number is taken to be in
+// options.Unit.
+//
+// The results keep the significant figures of the quantity, and of the
+// molar mass when converting between grams and the other units; Avogadro's
+// constant and the atom counts of the formula are exact. Formulas are
+// parsed as MolarMassService parses them, so groups, hydrates, isotopes,
+// abbreviations and SMILES are all accepted.
+func (service ConversionService) GetResponse(formula string, quantity units.Quantity, options ConversionOptions) (ConversionResponse, error) {
+	response := ConversionResponse{Formula: formula}
+	precision := options.Precision
+	if err := precision.Validate(); err != nil {
+		return response, err
+	}
+	compound, err := service.ParseCompound(formula)
+	if err != nil {
+		return response, err
+	}
+	elements, err := service.GetElements(compound)
+	if err != nil {
+		return response, err
+	}
+	isotopes, err := service.GetIsotopes(compound)
+	if err != nil {
+		return response, err
+	}
+
+	molarMass, places := compoundWeight(compound, elements, isotopes)
+	molarValue, _ := molarMass.Float64()
+	response.MolarMass = units.Join(precision.FormatExact(molarMass, places), units.GramPerMole)
+	if quantity.Value < 0 {
+		return response, inputErrorf("quantity must not be negative, got %s", quantity)
+	}
+
+	switch quantity.Unit.Dimension {
+	case units.Dimensionless:
+	case units.Mass:
+		options.Unit = GramUnit
+		quantity, err = quantity.In(units.Gram)
+	case units.Amount:
+		options.Unit = MoleUnit
+		quantity, err = quantity.In(units.Mole)
+	default:
+		err = inputErrorf("%w: %s is neither a mass nor an amount", units.ErrDimension, quantity)
+	}
+	if err != nil {
+		return response, err
+	}
+
+	var moles float64
+	switch options.Unit {
+	case "", GramUnit:
+		if molarValue == 0 {
+			return response, inputErrorf("%s has no mass to convert grams from", formula)
+		}
+		moles = quantity.Value / molarValue
+	case MoleUnit:
+		moles = quantity.Value
+	case ParticleUnit:
+		moles = quantity.Value / AvogadroConstant
+	case AtomUnit:
+		symbol := strings.TrimSpace(options.Element)
+		if compound.Data[symbol] == 0 {
+			return response, inputErrorf("%s has no atoms of %q to count", formula, symbol)
+		}
+		moles = quantity.Value / float64(compound.Data[symbol]) / AvogadroConstant
+	default:
+		return response, inputErrorf("unknown unit %q", options.Unit)
+	}
+
+	// Only the conversion between grams and the rest goes through the
+	// molar mass and is limited by its figures.
+	figures, gramFigures := quantity.Figures, min(quantity.Figures, significantPlaces(molarValue, places))
+	if options.Unit == "" || options.Unit == GramUnit {
+		figures, gramFigures = gramFigures, quantity.Figures
+	}
+
+	particles := moles * AvogadroConstant
+	response.Grams = units.Join(formatQuantity(precision, moles*molarValue, gramFigures), units.Gram)
+	response.Moles = units.Join(formatQuantity(precision, moles, figures), units.Mole)
+	response.Particles = precision.FormatScientific(particles, figures)
+	response.Atoms = make([]ConversionAtomInfo, len(elements))
+	for i, element := range elements {
+		response.Atoms[i] = ConversionAtomInfo{
+			Symbol: element.Symbol,
+			Name:   element.Name,
+			Count:  precision.FormatScientific(particles*float64(compound.Data[element.Symbol]), figures),
+		}
+	}
+	return response, nil
+}
+
+// formatQuantity writes a mass or an amount, switching to scientific
+// notation for the tiny quantities that counting particles gives.
+func formatQuantity(precision Precision, value float64, figures int) string {
+	if value != 0 && math.Abs(value) < 1e-4 {
+		return precision.FormatScientific(value, figures)
+	}
+	return precision.Format(value, figures)
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestConversion(t *testing.T) {
+	tests := []struct {
+		name      string
+		formula   string
+		quantity  string
+		options   ConversionOptions
+		grams     string
+		moles     string
+		particles string
+		atoms     map[string]string
+	}{
+		{
+			name: "grams", formula: "H2O", quantity: "36.0 g",
+			grams: "36.0 g", moles: "2.00 mol", particles: "1.20e24",
+			atoms: map[string]string{"H": "2.41e24", "O": "1.20e24"},
+		},
+		{
+			name: "bare number in grams", formula: "NaCl", quantity: "5.844",
+			grams: "5.844 g", moles: "0.1000 mol", particles: "6.022e22",
+		},
+		{
+			name: "millimoles", formula: "CO2", quantity: "250 mmol",
+			grams: "11.0 g", moles: "0.250 mol", particles: "1.51e23",
+		},
+		{
+			name: "particles", formula: "O2", quantity: "6.022e23", options: ConversionOptions{Unit: ParticleUnit},
+			grams: "32.00 g", moles: "1.000 mol", particles: "6.022e23",
+		},
+		{
+			name: "atoms of one element", formula: "CH4", quantity: "4.0e24", options: ConversionOptions{Unit: AtomUnit, Element: "H"},
+			grams: "27 g", moles: "1.7 mol", particles: "1.0e24",
+			atoms: map[string]string{"C": "1.0e24", "H": "4.0e24"},
+		},
+	}
+
+	service := ConversionService{testService(t)}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := service.GetResponse(test.formula, parseQuantity(t, test.quantity), test.options)
+			if err != nil {
+				t.Fatalf("GetResponse: %v", err)
+			}
+			if response.Grams != test.grams || response.Moles != test.moles || response.Particles != test.particles {
+				t.Errorf("GetResponse = %s, %s, %s; want %s, %s, %s", response.Grams, response.Moles, response.Particles,
+					test.grams, test.moles, test.particles)
+			}
+			for _, atom := range response.Atoms {
+				if want, ok := test.atoms[atom.Symbol]; ok && atom.Count != want {
+					t.Errorf("atoms of %s = %s, want %s", atom.Symbol, atom.Count, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConversionErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		formula  string
+		quantity string
+		options  ConversionOptions
+	}{
+		{name: "negative", formula: "H2O", quantity: "-1 g"},
+		{name: "volume", formula: "H2O", quantity: "1 L"},
+		{name: "element not in the compound", formula: "H2O", quantity: "1e23", options: ConversionOptions{Unit: AtomUnit, Element: "C"}},
+		{name: "unknown unit", formula: "H2O", quantity: "1", options: ConversionOptions{Unit: "dozen"}},
+		{name: "malformed formula", formula: "H2O)", quantity: "1 g"},
+	}
+
+	service := ConversionService{testService(t)}
+	for _, test := range tests {
+		if _, err := service.GetResponse(test.formula, parseQuantity(t, test.quantity), test.options); err == nil {
+			t.Errorf("%s: GetResponse succeeded, want an error", test.name)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"ChemistryPR/internal/database"
+	"ChemistryPR/internal/models"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrUnknownElement is wrapped by UnknownElementError.
+var ErrUnknownElement = errors.New("unknown element")
+
+// UnknownElementError lists the symbols of a formula that are not in the
+// periodic table together with known symbols they were probably meant to be.
+type UnknownElementError struct {
+	Formula     string              // Formula the symbols were found in
+	Symbols     []string            // Unknown symbols in alphabetical order
+	Suggestions map[string][]string // Close known symbols for every unknown one, possibly none
+}
+
+func (err *UnknownElementError) Error() string {
+	symbols := make([]string, len(err.Symbols))
+	for i, symbol := range err.Symbols {
+		symbols[i] = symbol
+		if suggestions := err.Suggestions[symbol]; len(suggestions) > 0 {
+			symbols[i] += fmt.Sprintf(" (did you mean %s?)", strings.Join(suggestions, ", "))
+		}
+	}
+	noun := "element"
+	if len(symbols) > 1 {
+		noun = "elements"
+	}
+	return fmt.Sprintf("unknown %s %s in %s", noun, strings.Join(symbols, "; "), err.Formula)
+}
+
+func (err *UnknownElementError) Unwrap() error {
+	return ErrUnknownElement
+}
+
+// ErrUnknownIsotope is returned for a labelled atom whose nuclide is not in
+// the isotopes table.
+var ErrUnknownIsotope = errors.New("unknown isotope")
+
+// maxSuggestions bounds the number of suggestions per unknown symbol.
+const maxSuggestions = 3
+
+// GetElements retrieves every element of compound from the store.
+//
+// Unlike database.Store.GetElements it does not stop at the first missing
+// symbol: all of them are collected into an *UnknownElementError, each with
+// suggestions drawn from the symbols of the periodic table.
+func (service ChemicalService) GetElements(compound models.Compound) ([]models.Element, error) {
+	symbols := make([]string, 0, len(compound.Data))
+	for symbol := range compound.Data {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	elements := make([]models.Element, 0, len(symbols))
+	var unknown []string
+	for _, symbol := range symbols {
+		element, err := service.Store.GetElement(symbol)
+		if errors.Is(err, database.ErrElementNotFound) {
+			unknown = append(unknown, symbol)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+	if len(unknown) == 0 {
+		return elements, nil
+	}
+
+	known, err := service.Store.GetSymbols()
+	if err != nil {
+		return nil, err
+	}
+	suggestions := make(map[string][]string, len(unknown))
+	for _, symbol := range unknown {
+		suggestions[symbol] = suggestSymbols(symbol, known)
+		// "AI" typed for "Al" parses as the unknown "A" followed by iodine.
+		if strings.Contains(compound.Formula, symbol+"I") {
+			suggestions[symbol] = mergeSuggestions(suggestSymbols(symbol+"I", known), suggestions[symbol])
+		}
+	}
+	return nil, &UnknownElementError{Formula: compound.Formula, Symbols: unknown, Suggestions: suggestions}
+}
+
+// GetIsotopes retrieves the nuclides of the labelled atoms of compound from
+// the store, keyed by nuclide.
+func (service ChemicalService) GetIsotopes(compound models.Compound) (map[models.Nuclide]models.Isotope, error) {
+	isotopes := make(map[models.Nuclide]models.Isotope, len(compound.Isotopes))
+	for nuclide := range compound.Isotopes {
+		isotope, err := service.Store.GetIsotope(nuclide)
+		if errors.Is(err, database.ErrIsotopeNotFound) {
+			return nil, inputErrorf("%w %s in %s", ErrUnknownIsotope, nuclide, compound.Formula)
+		}
+		if err != nil {
+			return nil, err
+		}
+		isotopes[nuclide] = isotope
+	}
+	return isotopes, nil
+}
+
+// suggestSymbols returns the known symbols closest to symbol, at most
+// maxSuggestions of them and none farther than one edit away.
+func suggestSymbols(symbol string, known []string) []string {
+	best := 2
+	var suggestions []string
+	for _, candidate := range known {
+		distance := symbolDistance(symbol, candidate)
+		switch {
+		case distance < best:
+			best = distance
+			suggestions = []string{candidate}
+		case distance == best:
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions
+}
+
+// mergeSuggestions joins two suggestion lists without duplicates, keeping
+// at most maxSuggestions of them.
+func mergeSuggestions(first, second []string) []string {
+	var merged []string
+	for _, symbol := range append(first, second...) {
+		if len(merged) < maxSuggestions && !slices.Contains(merged, symbol) {
+			merged = append(merged, symbol)
+		}
+	}
+	return merged
+}
+
+// confusedSymbols returns a warning for every "CI" in formula that parses
+// as carbon and iodine but was probably meant as chlorine, as in "NaCI".
+// Unlike "AI", which leaves the unknown "A" behind, "CI" always parses, so
+// it cannot be caught by the unknown symbols; "CIn" and "CIr" are left
+// alone.
+func confusedSymbols(formula string) []string {
+	runes := []rune(formula)
+	var warnings []string
+	for i := 0; i+1 < len(runes); i++ {
+		if runes[i] != 'C' || runes[i+1] != 'I' {
+			continue
+		}
+		if i+2 < len(runes) && unicode.IsLower(runes[i+2]) {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf(`"CI" at position %d of %s is read as carbon and iodine; did you mean Cl?`, i+1, formula))
+	}
+	return warnings
+}
+
+// symbolDistance is the edit distance between two symbols that ignores
+// case and treats the easily confused "I" and "l" as the same letter, so
+// "AI" is at distance zero from "Al".
+func symbolDistance(a, b string) int {
+	normalize := func(symbol string) []rune {
+		return []rune(strings.ReplaceAll(strings.ToLower(symbol), "i", "l"))
+	}
+	x, y := normalize(a), normalize(b)
+
+	previous := make([]int, len(y)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		current := make([]int, len(y)+1)
+		current[0] = i
+		for j := 1; j <= len(y); j++ {
+			substitution := previous[j-1]
+			if x[i-1] != y[j-1] {
+				substitution++
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, substitution)
+		}
+		previous = current
+	}
+	return previous[len(y)]
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"ChemistryPR/internal/models"
+	"ChemistryPR/internal/units"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// EmpiricalFormulaService is the reverse of MolarMassService: it finds
+// the formula of a compound from the mass percentages of its elements.
+// It embeds the ChemicalService to reach the periodic table.
+type EmpiricalFormulaService struct {
+	ChemicalService
+}
+
+// EmpiricalFormulaOptions holds the optional parameters of an empirical
+// formula request.
+type EmpiricalFormulaOptions struct {
+	MolarMass units.Quantity // Known molar mass, g/mol for a bare number; zero when unknown
+	Precision Precision      // Rounding of the masses, moles and ratios
+}
+
+// EmpiricalFormulaResponse is the result of an empirical formula
+// calculation.
+type EmpiricalFormulaResponse struct {
+	Composition   string
+	PercentTotal  string                        // Sum of the entered percentages
+	Empirical     string                        // Empirical formula in Hill order, e.g. "CH2O"
+	EmpiricalMass string                        // Molar mass of the empirical formula, e.g. "30.026 g/mol"
+	Multiplier    string                        // Factor that cleared fractional mole ratios, e.g. "2" for ratios of 1 : 1.5
+	Molecular     string                        // Molecular formula, empty without a molar mass
+	MolecularMass string                        // Molar mass of the molecular formula, empty without a molar mass
+	Units         string                        // Empirical units per molecule, empty without a molar mass
+	UnitsResidual string                        // Molar mass over empirical mass minus Units, empty without a molar mass
+	Elements      []EmpiricalFormulaElementInfo // Elements in the order they were entered
+}
+
+// EmpiricalFormulaElementInfo follows one element through the
+// calculation: its moles in 100 g of compound, its ratio to the
+// scarcest element and how far that ratio was rounded.
+type EmpiricalFormulaElementInfo struct {
+	Symbol   string
+	Name     string // Name of the element
+	Percent  string // Entered mass percentage
+	Moles    string // Moles in 100 g of the compound
+	Ratio    string // Moles relative to the element with the fewest moles, times the multiplier
+	Count    string // Atoms in the empirical formula
+	Residual string // Ratio minus Count
+}
+
+// maxEmpiricalMultiplier bounds the factors tried to turn fractional mole
+// ratios such as 1.5 or 1.33 into whole numbers.
+const maxEmpiricalMultiplier = 8
+
+// empiricalTolerance is how far from a whole number a mole ratio may be
+// and still be rounded to it.
+const empiricalTolerance = 0.1
+
+// massShare is one "element percentage" entry of a composition.
+type massShare struct {
+	symbol  string
+	percent units.Quantity
+}
+
+// compositionEntry matches "C 40.0", "C: 40,0 %" or "C=40".
+var compositionEntry = regexp.MustCompile(`([A-Z][a-z]{0,2})\s*[:=]?\s*(\d+(?:[.,]\d+)?)\s*%?`)
+
+// compositionSeparator matches what may stand between entries.
+var compositionSeparator = regexp.MustCompile(`^[\s,;]*$`)
+
+// GetResponse computes the empirical formula of a compound from the mass
+// percentages of its elements, written as "C 40.0, H 6.7, O 53.3", and
+// its molecular formula when options.MolarMass is set.
+//
+// The percentages are turned into moles per 100 g using the atomic
+// weights of the periodic table and divided by the smallest of them. When
+// the ratios are not whole numbers they are multiplied by the smallest
+// factor up to maxEmpiricalMultiplier that brings them all within
+// empiricalTolerance of one, or else by the factor that comes closest.
+// The percentages need not add up to 100: only their ratios matter.
+//
+// By default moles and ratios keep the significant figures of the least
+// precise percentage or atomic weight they come from, and masses are
+// written as MolarMassService writes them.
+//
+// Malformed compositions are reported as a *ParseError, symbols missing
+// from the periodic table as an *UnknownElementError.
+func (service EmpiricalFormulaService) GetResponse(composition string, options EmpiricalFormulaOptions) (EmpiricalFormulaResponse, error) {
+	shares, err := parseComposition(composition)
+	if err != nil {
+		return EmpiricalFormulaResponse{Composition: composition}, err
+	}
+	return service.fromShares(composition, shares, options)
+}
+
+// fromShares computes the empirical and molecular formulas of the parsed
+// entries of composition.
+func (service EmpiricalFormulaService) fromShares(composition string, shares []massShare, options EmpiricalFormulaOptions) (EmpiricalFormulaResponse, error) {
+	response := EmpiricalFormulaResponse{Composition: composition}
+	if err := options.Precision.Validate(); err != nil {
+		return response, err
+	}
+	compound := models.Compound{Formula: composition, Data: make(map[string]int, len(shares))}
+	for _, share := range shares {
+		compound.Data[share.symbol] = 1
+	}
+	elements, err := service.GetElements(compound)
+	if err != nil {
+		return response, err
+	}
+	bySymbol := make(map[string]models.Element, len(elements))
+	for _, element := range elements {
+		bySymbol[element.Symbol] = element
+	}
+
+	precision := options.Precision
+	total := new(big.Rat)
+	totalPlaces := 0
+	moles := make([]float64, len(shares))
+	molesFigures := make([]int, len(shares))
+	fewest := 0
+	for i, share := range shares {
+		percent, _ := exactDecimal(share.percent.Value)
+		total.Add(total, percent)
+		if places := decimalPlaces(share.percent.Value, share.percent.Figures); i == 0 || places < totalPlaces {
+			totalPlaces = places
+		}
+		weight, places := exactDecimal(bySymbol[share.symbol].AtomicWeight)
+		weightValue, _ := weight.Float64()
+		moles[i] = share.percent.Value / weightValue
+		molesFigures[i] = min(share.percent.Figures, significantPlaces(weightValue, places))
+		if moles[i] < moles[fewest] {
+			fewest = i
+		}
+	}
+	ratios := make([]float64, len(shares))
+	for i := range moles {
+		ratios[i] = moles[i] / moles[fewest]
+	}
+
+	multiplier := wholeMultiplier(ratios)
+	response.Elements = make([]EmpiricalFormulaElementInfo, len(shares))
+	for i, share := range shares {
+		element := bySymbol[share.symbol]
+		ratio := ratios[i] * float64(multiplier)
+		ratioFigures := min(molesFigures[i], molesFigures[fewest])
+		count := max(int(math.Round(ratio)), 1)
+		compound.Data[share.symbol] = count
+		response.Elements[i] = EmpiricalFormulaElementInfo{
+			Symbol:   element.Symbol,
+			Name:     element.Name,
+			Percent:  precision.Format(share.percent.Value, share.percent.Figures),
+			Moles:    units.Join(precision.Format(moles[i], molesFigures[i]), units.Mole),
+			Ratio:    precision.Format(ratio, ratioFigures),
+			Count:    fmt.Sprint(count),
+			Residual: formatResidual(precision, ratio-float64(count), decimalPlaces(ratio, ratioFigures)),
+		}
+	}
+	empiricalMass, massPlaces := compoundWeight(compound, elements, nil)
+	response.PercentTotal = precision.FormatExact(total, totalPlaces)
+	response.Empirical = CanonicalFormula(compound)
+	response.EmpiricalMass = units.Join(precision.FormatExact(empiricalMass, massPlaces), units.GramPerMole)
+	response.Multiplier = fmt.Sprint(multiplier)
+
+	if options.MolarMass.Value == 0 {
+		return response, nil
+	}
+	molarMass, err := options.MolarMass.Default(units.GramPerMole).In(units.GramPerMole)
+	if err != nil {
+		return response, err
+	}
+	if molarMass.Value < 0 {
+		return response, inputErrorf("molar mass must be positive, got %g", molarMass.Value)
+	}
+	empiricalValue, _ := empiricalMass.Float64()
+	multiple := molarMass.Value / empiricalValue
+	if math.Round(multiple) < 1 {
+		return response, inputErrorf("molar mass %s is below the mass %s of the empirical formula %s",
+			molarMass, response.EmpiricalMass, response.Empirical)
+	}
+	rounded := int(math.Round(multiple))
+	for symbol := range compound.Data {
+		compound.Data[symbol] *= rounded
+	}
+	molecularMass, _ := compoundWeight(compound, elements, nil)
+	unitsFigures := min(molarMass.Figures, significantPlaces(empiricalValue, massPlaces))
+	response.Molecular = CanonicalFormula(compound)
+	response.MolecularMass = units.Join(precision.FormatExact(molecularMass, massPlaces), units.GramPerMole)
+	response.Units = fmt.Sprint(rounded)
+	response.UnitsResidual = formatResidual(precision, multiple-float64(rounded), decimalPlaces(multiple, unitsFigures))
+	return response, nil
+}
+
+// formatResidual writes the difference between a value and the whole
+// number it was rounded to, with its sign, to the decimal places of the
+// value.
+func formatResidual(precision Precision, residual float64, places int) string {
+	exact, _ := exactDecimal(residual)
+	text := precision.FormatExact(exact, places)
+	if !strings.HasPrefix(text, "-") {
+		text = "+" + text
+	}
+	return text
+}
+
+// wholeMultiplier returns the smallest factor that brings every ratio
+// within empiricalTolerance of a whole number, or the factor up to
+// maxEmpiricalMultiplier that leaves the smallest worst residual.
+func wholeMultiplier(ratios []float64) int {
+	best, bestResidual := 1, math.Inf(1)
+	for multiplier := 1; multiplier <= maxEmpiricalMultiplier; multiplier++ {
+		worst := 0.0
+		for _, ratio := range ratios {
+			scaled := ratio * float64(multiplier)
+			worst = max(worst, math.Abs(scaled-math.Round(scaled)))
+		}
+		if worst <= empiricalTolerance {
+			return multiplier
+		}
+		if worst < bestResidual {
+			best, bestResidual = multiplier, worst
+		}
+	}
+	return best
+}
+
+// parseComposition reads the "element percentage" entries of a
+// composition. Entries may be separated by commas, semicolons or spaces,
+// and percentages may use a decimal comma.
+func parseComposition(composition string) ([]massShare, error) {
+	fail := func(offset int, expected string) error {
+		column := utf8.RuneCountInString(composition[:offset]) + 1
+		found := "end of composition"
+		if offset < len(composition) {
+			r, _ := utf8.DecodeRuneInString(composition[offset:])
+			found = strconv.Quote(string(r))
+		}
+		return &ParseError{Formula: composition, Column: column, Expected: expected, Found: found}
+	}
+	// unexpected reports the first character from offset on that is not a separator.
+	unexpected := func(offset int) error {
+		rest := composition[offset:]
+		return fail(offset+len(rest)-len(strings.TrimLeft(rest, " \t\r\n,;")),
+			`element symbol and mass percentage, e.g. "C 40.0"`)
+	}
+
+	var shares []massShare
+	seen := make(map[string]bool)
+	position := 0
+	for _, match := range compositionEntry.FindAllStringSubmatchIndex(composition, -1) {
+		if !compositionSeparator.MatchString(composition[position:match[0]]) {
+			return nil, unexpected(position)
+		}
+		symbol := composition[match[2]:match[3]]
+		if seen[symbol] {
+			return nil, fail(match[2], "element not entered before")
+		}
+		seen[symbol] = true
+		percent, err := units.Parse(composition[match[4]:match[5]])
+		if err != nil || percent.Value == 0 {
+			return nil, fail(match[4], "non-zero mass percentage")
+		}
+		shares = append(shares, massShare{symbol: symbol, percent: percent})
+		position = match[1]
+	}
+	if !compositionSeparator.MatchString(composition[position:]) || len(shares) == 0 {
+		return nil, unexpected(position)
+	}
+	return shares, nil
+}
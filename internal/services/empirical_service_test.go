@@ -0,0 +1,54 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEmpiricalFormula(t *testing.T) {
+	tests := []struct {
+		composition string
+		molarMass   string
+		empirical   string
+		multiplier  string
+		molecular   string
+	}{
+		{composition: "C 40.0, H 6.7, O 53.3", empirical: "CH2O", multiplier: "1"},
+		{composition: "C 40.0, H 6.7, O 53.3", molarMass: "180 g/mol", empirical: "CH2O", multiplier: "1", molecular: "C6H12O6"},
+		{composition: "C: 92.3 %; H: 7.7 %", molarMass: "78", empirical: "CH", multiplier: "1", molecular: "C6H6"},
+		{composition: "Fe 69.9, O 30.1", empirical: "Fe2O3", multiplier: "2"},
+		{composition: "K=26.6 Cr=35.4 O=38.0", empirical: "Cr2K2O7", multiplier: "2"},
+		{composition: "C 20, H 5", empirical: "CH3", multiplier: "1"},
+	}
+
+	service := EmpiricalFormulaService{testService(t)}
+	for _, test := range tests {
+		response, err := service.GetResponse(test.composition, EmpiricalFormulaOptions{MolarMass: parseQuantity(t, test.molarMass)})
+		if err != nil {
+			t.Errorf("GetResponse(%q): %v", test.composition, err)
+			continue
+		}
+		if response.Empirical != test.empirical || response.Multiplier != test.multiplier || response.Molecular != test.molecular {
+			t.Errorf("GetResponse(%q) = %s times %s, molecular %q; want %s times %s, molecular %q", test.composition,
+				response.Empirical, response.Multiplier, response.Molecular, test.empirical, test.multiplier, test.molecular)
+		}
+	}
+}
+
+func TestEmpiricalFormulaErrors(t *testing.T) {
+	service := EmpiricalFormulaService{testService(t)}
+
+	var parseError *ParseError
+	for _, composition := range []string{"", "C 40.0, H", "40 C"} {
+		if _, err := service.GetResponse(composition, EmpiricalFormulaOptions{}); !errors.As(err, &parseError) {
+			t.Errorf("GetResponse(%q) error = %v, want a *ParseError", composition, err)
+		}
+	}
+	var unknownElement *UnknownElementError
+	if _, err := service.GetResponse("Xx 50, O 50", EmpiricalFormulaOptions{}); !errors.As(err, &unknownElement) {
+		t.Errorf("GetResponse with an unknown element error = %v, want an *UnknownElementError", err)
+	}
+	if _, err := service.GetResponse("C 40.0, H 6.7, O 53.3", EmpiricalFormulaOptions{MolarMass: parseQuantity(t, "10 g/mol")}); err == nil {
+		t.Errorf("GetResponse with a molar mass below the empirical one succeeded, want an error")
+	}
+}
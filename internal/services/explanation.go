@@ -0,0 +1,68 @@
+package services
+
+import (
+	"math/big"
+)
+
+// ReductionStep is one elementary row operation of the row reduction.
+type ReductionStep struct {
+	Operation string     // The operation, e.g. "R2 ← R2 − 2·R1"
+	Matrix    [][]string // The matrix after the operation
+}
+
+// BalanceExplanation records how the native balancer reached its answer.
+type BalanceExplanation struct {
+	Species  []string   // Column labels, reagents first
+	Elements []string   // Row labels, ending with "charge" for ionic equations
+	Matrix   [][]string // Composition matrix with product counts negated
+	Steps    []ReductionStep
+
+	FreeVariables []string // Species whose coefficients are chosen freely, one per dimension of the solution space
+	Solution      []string // Rational solution with the free variable set to 1, when it is unique
+	LCM           string   // Least common multiple of the solution's denominators
+	Coefficients  []int    // Solution multiplied by LCM and reduced to the smallest integers
+}
+
+// explainer collects the working of a balancing while it happens.
+type explainer struct {
+	explanation *BalanceExplanation
+	matrix      ratMatrix
+}
+
+// newExplainer starts an explanation of the given composition matrix.
+func newExplainer(equation Equation, elements []string, matrix ratMatrix) *explainer {
+	return &explainer{
+		explanation: &BalanceExplanation{
+			Species:  equation.Species(),
+			Elements: elements,
+			Matrix:   matrix.strings(),
+		},
+		matrix: matrix,
+	}
+}
+
+// trace records a row operation together with the resulting matrix.
+func (explainer *explainer) trace(operation string) {
+	explainer.explanation.Steps = append(explainer.explanation.Steps,
+		ReductionStep{Operation: operation, Matrix: explainer.matrix.strings()})
+}
+
+// nullspace records the free variables and, for a one-dimensional
+// nullspace, the rational solution and its scaling to integers.
+func (explainer *explainer) nullspace(basis [][]*big.Rat, frees []int) {
+	species := explainer.explanation.Species
+	for _, free := range frees {
+		explainer.explanation.FreeVariables = append(explainer.explanation.FreeVariables, species[free])
+	}
+	if len(basis) != 1 {
+		return
+	}
+
+	lcm := big.NewInt(1)
+	for _, value := range basis[0] {
+		explainer.explanation.Solution = append(explainer.explanation.Solution, value.RatString())
+		denominator := value.Denom()
+		lcm.Mul(lcm, new(big.Int).Quo(denominator, new(big.Int).GCD(nil, nil, lcm, denominator)))
+	}
+	explainer.explanation.LCM = lcm.String()
+}
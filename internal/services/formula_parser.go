@@ -0,0 +1,507 @@
+package services
+
+import (
+	"ChemistryPR/internal/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseError reports a syntax error in a formula together with the
+// position at which the parser gave up.
+type ParseError struct {
+	Formula  string // The formula being parsed
+	Column   int    // 1-based position, in characters, of the offending token
+	Expected string // What the parser expected at Column, e.g. "element symbol or group"
+	Found    string // What it found instead, "end of formula" past the last character
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("%s: column %d: expected %s, found %s", err.Formula, err.Column, err.Expected, err.Found)
+}
+
+// Caret renders the formula with a caret under the offending column.
+func (err *ParseError) Caret() string {
+	return err.Formula + "\n" + strings.Repeat(" ", err.Column-1) + "^"
+}
+
+// tokenKind classifies the tokens of a formula.
+type tokenKind int
+
+const (
+	tokenEnd       tokenKind = iota // End of the input
+	tokenInvalid                    // A character that starts no token
+	tokenElement                    // An upper-case letter and the lower-case letters after it, optionally after a lower-case prefix as in "tBu"
+	tokenCount                      // A run of digits
+	tokenOpen                       // "(", "[" or "{"
+	tokenClose                      // ")", "]" or "}"
+	tokenSeparator                  // "·", "•", "*" or "." between the parts of an adduct
+	tokenCaret                      // "^" before a charge
+	tokenSign                       // "+" or "-"
+	tokenElectron                   // "e", the free electron
+	tokenState                      // A state label ending the input: "(g)", "(l)", "(s)" or "(aq)"
+)
+
+// token is a lexeme of a formula and its 1-based column.
+type token struct {
+	kind   tokenKind
+	text   string
+	column int
+}
+
+// describe returns how a token is named in a ParseError.
+func (t token) describe() string {
+	if t.kind == tokenEnd {
+		return "end of formula"
+	}
+	return strconv.Quote(t.text)
+}
+
+// stateLabels are the state labels a species may end with, by the state
+// they stand for.
+var stateLabels = map[string]string{"(g)": "g", "(l)": "l", "(s)": "s", "(aq)": "aq"}
+
+// closingBrackets maps every opening bracket of a group to its closing one.
+var closingBrackets = map[string]string{"(": ")", "[": "]", "{": "}"}
+
+// tokenize splits a formula into tokens. It never fails: characters that
+// start no token become tokenInvalid and are reported by the parser, which
+// knows what it expected in their place.
+func tokenize(formula string) []token {
+	runes := []rune(formula)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		start := i
+		kind := tokenInvalid
+		switch r := runes[i]; {
+		case stateLabels[string(runes[i:])] != "":
+			kind, i = tokenState, len(runes)
+		case unicode.IsUpper(r):
+			kind = tokenElement
+			for i++; i < len(runes) && unicode.IsLower(runes[i]); i++ {
+			}
+		case unicode.IsDigit(r):
+			kind = tokenCount
+			for i++; i < len(runes) && unicode.IsDigit(runes[i]); i++ {
+			}
+		case strings.ContainsRune("([{", r):
+			kind, i = tokenOpen, i+1
+		case strings.ContainsRune(")]}", r):
+			kind, i = tokenClose, i+1
+		case strings.ContainsRune("·•*.", r):
+			kind, i = tokenSeparator, i+1
+		case r == '^':
+			kind, i = tokenCaret, i+1
+		case r == '+' || r == '-':
+			kind, i = tokenSign, i+1
+		case unicode.IsLower(r) && i+1 < len(runes) && unicode.IsUpper(runes[i+1]):
+			kind = tokenElement
+			for i += 2; i < len(runes) && unicode.IsLower(runes[i]); i++ {
+			}
+		case r == 'e' && start == 0:
+			kind, i = tokenElectron, i+1
+		default:
+			i++
+		}
+		tokens = append(tokens, token{kind: kind, text: string(runes[start:i]), column: start + 1})
+	}
+	return append(tokens, token{kind: tokenEnd, column: len(runes) + 1})
+}
+
+// formulaParser is a recursive-descent parser over the tokens of a formula:
+//
+//	species   = electron charge | formula [charge] [state]
+//	formula   = part { separator [count] part }
+//	part      = unit { unit }
+//	unit      = ( element | abbreviation | isotope | group ) [count]
+//	group     = "(" part ")" | "[" part "]" | "{" part "}"
+//	isotope   = "[" count element "]"
+//	charge    = "^" ( count sign | sign [count] ) | sign count | sign { sign }
+//	state     = "(g)" | "(l)" | "(s)" | "(aq)"
+//
+// Element symbols have one to three letters, and "D" and "T" stand for
+// hydrogen-2 and hydrogen-3; runs of signs must repeat the same sign.
+// An abbreviation may span several element tokens, as "OAc" and "AcO" do,
+// and the longest one starting at a unit is read before any element, so
+// "AcOH" is acetic acid while "AcCl3" is actinium chloride.
+type formulaParser struct {
+	input         string
+	tokens        []token
+	position      int
+	abbreviations map[string]string // Formulas of the functional-group abbreviations, e.g. "Ph": "C6H5"
+}
+
+// parseFormula parses a whole species into a compound, expanding the given
+// abbreviations, which may be nil.
+func parseFormula(formula string, abbreviations map[string]string) (models.Compound, error) {
+	parser := &formulaParser{input: formula, tokens: tokenize(formula), abbreviations: abbreviations}
+	return parser.species()
+}
+
+// peek returns the current token.
+func (parser *formulaParser) peek() token {
+	return parser.tokens[parser.position]
+}
+
+// next returns the current token and advances past it.
+func (parser *formulaParser) next() token {
+	current := parser.tokens[parser.position]
+	if current.kind != tokenEnd {
+		parser.position++
+	}
+	return current
+}
+
+// fail builds a ParseError at the current token.
+func (parser *formulaParser) fail(expected string) error {
+	return parser.failAt(parser.peek(), expected)
+}
+
+// failAt builds a ParseError at the given token.
+func (parser *formulaParser) failAt(at token, expected string) error {
+	return &ParseError{Formula: parser.input, Column: at.column, Expected: expected, Found: at.describe()}
+}
+
+// species parses a free electron or a formula followed by an optional
+// charge, an optional state label and the end of the input.
+func (parser *formulaParser) species() (models.Compound, error) {
+	if parser.peek().kind == tokenElectron {
+		parser.next()
+		at := parser.peek()
+		charge, err := parser.charge()
+		if err != nil {
+			return models.Compound{}, err
+		}
+		if charge != -1 {
+			return models.Compound{}, parser.failAt(at, `electron charge "-"`)
+		}
+		if parser.peek().kind != tokenEnd {
+			return models.Compound{}, parser.fail("end of formula")
+		}
+		return models.Compound{Formula: "e-", Data: map[string]int{}, Charge: -1}, nil
+	}
+
+	compound, err := parser.formula()
+	if err != nil {
+		return models.Compound{}, err
+	}
+	switch parser.peek().kind {
+	case tokenEnd, tokenState:
+	case tokenCaret, tokenSign:
+		if compound.Charge, err = parser.charge(); err != nil {
+			return models.Compound{}, err
+		}
+		if kind := parser.peek().kind; kind != tokenEnd && kind != tokenState {
+			return models.Compound{}, parser.fail("state label or end of formula")
+		}
+	default:
+		return models.Compound{}, parser.fail("element symbol, group, count, charge or end of formula")
+	}
+	if parser.peek().kind == tokenState {
+		compound.State = stateLabels[parser.next().text]
+	}
+	return compound, nil
+}
+
+// formula parses the parts of an adduct and sums their counts. The parts
+// are kept in Adducts only when there is more than one.
+func (parser *formulaParser) formula() (models.Compound, error) {
+	total := newAtomCounts()
+	var compound models.Compound
+
+	multiplier := 1
+	for {
+		start := parser.position
+		atoms, err := parser.part()
+		if err != nil {
+			return models.Compound{}, err
+		}
+		total.add(atoms, multiplier)
+		compound.Adducts = append(compound.Adducts, models.Adduct{
+			Formula:    parser.text(start, parser.position),
+			Multiplier: multiplier,
+			Data:       atoms.elements,
+			Isotopes:   atoms.isotopes,
+		})
+
+		if parser.peek().kind != tokenSeparator {
+			break
+		}
+		parser.next()
+		multiplier = 1
+		if parser.peek().kind == tokenCount {
+			if multiplier, err = parser.count(); err != nil {
+				return models.Compound{}, err
+			}
+		}
+	}
+
+	compound.Formula = parser.text(0, parser.position)
+	compound.Expanded = parser.expandedText(parser.position)
+	compound.Data = total.elements
+	compound.Isotopes = total.isotopes
+	if len(compound.Adducts) == 1 {
+		compound.Adducts = nil
+	}
+	return compound, nil
+}
+
+// text joins the tokens in [from, to) back into the formula they came from.
+func (parser *formulaParser) text(from, to int) string {
+	var text strings.Builder
+	for _, t := range parser.tokens[from:to] {
+		text.WriteString(t.text)
+	}
+	return text.String()
+}
+
+// expandedText joins the tokens before position back into a formula with
+// every abbreviation replaced by its formula, in parentheses when a count
+// follows, as in "(C2H5)2O" for "Et2O".
+func (parser *formulaParser) expandedText(position int) string {
+	var text strings.Builder
+	for i := 0; i < position; i++ {
+		length := parser.abbreviationLength(i)
+		if length == 0 || i+length > position {
+			text.WriteString(parser.tokens[i].text)
+			continue
+		}
+		formula := parser.abbreviations[parser.text(i, i+length)]
+		if parser.tokens[i+length].kind == tokenCount {
+			formula = "(" + formula + ")"
+		}
+		text.WriteString(formula)
+		i += length - 1
+	}
+	return text.String()
+}
+
+// abbreviationLength returns the number of element tokens of the longest
+// abbreviation starting at position, zero when none does.
+func (parser *formulaParser) abbreviationLength(position int) int {
+	length := 0
+	for end := position; end < len(parser.tokens) && parser.tokens[end].kind == tokenElement; end++ {
+		if parser.abbreviations[parser.text(position, end+1)] != "" {
+			length = end + 1 - position
+		}
+	}
+	return length
+}
+
+// atomCounts accumulates the atoms of a part of a formula: every atom by
+// element and the labelled ones also by nuclide.
+type atomCounts struct {
+	elements map[string]int
+	isotopes map[models.Nuclide]int
+}
+
+func newAtomCounts() atomCounts {
+	return atomCounts{elements: make(map[string]int), isotopes: make(map[models.Nuclide]int)}
+}
+
+// add adds multiplier times the atoms of other.
+func (counts atomCounts) add(other atomCounts, multiplier int) {
+	for symbol, count := range other.elements {
+		counts.elements[symbol] += count * multiplier
+	}
+	for nuclide, count := range other.isotopes {
+		counts.isotopes[nuclide] += count * multiplier
+	}
+}
+
+// hydrogenIsotopes are the nuclides with a symbol of their own.
+var hydrogenIsotopes = map[string]models.Nuclide{
+	"D": {Symbol: "H", MassNumber: 2},
+	"T": {Symbol: "H", MassNumber: 3},
+}
+
+// part parses one or more units and returns their atoms.
+func (parser *formulaParser) part() (atomCounts, error) {
+	counts := newAtomCounts()
+	for first := true; ; first = false {
+		kind := parser.peek().kind
+		if kind != tokenElement && kind != tokenOpen {
+			if first {
+				return atomCounts{}, parser.fail("element symbol or group")
+			}
+			return counts, nil
+		}
+		if err := parser.unit(counts); err != nil {
+			return atomCounts{}, err
+		}
+	}
+}
+
+// unit parses an element, an isotope or a group with its optional count
+// and adds the result to counts.
+func (parser *formulaParser) unit(counts atomCounts) error {
+	var atoms atomCounts
+	switch {
+	case parser.peek().text == "[" && parser.tokens[parser.position+1].kind == tokenCount:
+		nuclide, err := parser.isotope()
+		if err != nil {
+			return err
+		}
+		atoms = newAtomCounts()
+		atoms.elements[nuclide.Symbol] = 1
+		atoms.isotopes[nuclide] = 1
+	case parser.peek().kind == tokenOpen:
+		closing := closingBrackets[parser.next().text]
+		group, err := parser.part()
+		if err != nil {
+			return err
+		}
+		if current := parser.peek(); current.kind != tokenClose || current.text != closing {
+			return parser.fail(strconv.Quote(closing))
+		}
+		parser.next()
+		atoms = group
+	case parser.abbreviationLength(parser.position) > 0:
+		var err error
+		if atoms, err = parser.abbreviation(); err != nil {
+			return err
+		}
+	default:
+		symbol, err := parser.element()
+		if err != nil {
+			return err
+		}
+		atoms = newAtomCounts()
+		if nuclide, ok := hydrogenIsotopes[symbol]; ok {
+			atoms.elements[nuclide.Symbol] = 1
+			atoms.isotopes[nuclide] = 1
+		} else {
+			atoms.elements[symbol] = 1
+		}
+	}
+
+	multiplier := 1
+	if parser.peek().kind == tokenCount {
+		var err error
+		if multiplier, err = parser.count(); err != nil {
+			return err
+		}
+	}
+	counts.add(atoms, multiplier)
+	return nil
+}
+
+// element parses an element symbol of one to three letters.
+func (parser *formulaParser) element() (string, error) {
+	element := parser.peek()
+	if !unicode.IsUpper([]rune(element.text)[0]) {
+		return "", parser.fail("element symbol or abbreviation")
+	}
+	parser.next()
+	if length := len([]rune(element.text)); length > 3 {
+		return "", &ParseError{
+			Formula:  parser.input,
+			Column:   element.column + 3,
+			Expected: "element symbol of at most three letters",
+			Found:    strconv.Quote(element.text),
+		}
+	}
+	return element.text, nil
+}
+
+// abbreviation parses a functional-group abbreviation and returns the
+// atoms of the formula it stands for.
+func (parser *formulaParser) abbreviation() (atomCounts, error) {
+	start := parser.position
+	parser.position += parser.abbreviationLength(start)
+	abbreviation := parser.text(start, parser.position)
+	compound, err := parseFormula(parser.abbreviations[abbreviation], nil)
+	if err != nil {
+		return atomCounts{}, fmt.Errorf("abbreviation %s: %w", abbreviation, err)
+	}
+	return atomCounts{elements: compound.Data, isotopes: compound.Isotopes}, nil
+}
+
+// isotope parses a mass number and an element symbol in square brackets,
+// as in "[13C]".
+func (parser *formulaParser) isotope() (models.Nuclide, error) {
+	parser.next()
+	massNumber, err := parser.count()
+	if err != nil {
+		return models.Nuclide{}, err
+	}
+	if parser.peek().kind != tokenElement {
+		return models.Nuclide{}, parser.fail("element symbol")
+	}
+	symbol, err := parser.element()
+	if err != nil {
+		return models.Nuclide{}, err
+	}
+	if _, ok := hydrogenIsotopes[symbol]; ok {
+		return models.Nuclide{}, parser.failAt(parser.tokens[parser.position-1], "element symbol other than D or T")
+	}
+	if parser.peek().text != "]" {
+		return models.Nuclide{}, parser.fail(`"]"`)
+	}
+	parser.next()
+	return models.Nuclide{Symbol: symbol, MassNumber: massNumber}, nil
+}
+
+// count parses a positive integer.
+func (parser *formulaParser) count() (int, error) {
+	current := parser.peek()
+	value, err := strconv.Atoi(current.text)
+	if current.kind != tokenCount || err != nil || value == 0 {
+		return 0, parser.fail("positive count")
+	}
+	parser.next()
+	return value, nil
+}
+
+// sign parses "+" or "-" and returns 1 or -1.
+func (parser *formulaParser) sign() (int, error) {
+	if parser.peek().kind != tokenSign {
+		return 0, parser.fail(`charge sign "+" or "-"`)
+	}
+	if parser.next().text == "-" {
+		return -1, nil
+	}
+	return 1, nil
+}
+
+// charge parses "^2+", "^+2", "^-", "+3", "-", "++" and similar suffixes
+// and returns the signed charge.
+func (parser *formulaParser) charge() (int, error) {
+	if parser.peek().kind == tokenCaret {
+		parser.next()
+		if parser.peek().kind == tokenCount {
+			magnitude, err := parser.count()
+			if err != nil {
+				return 0, err
+			}
+			direction, err := parser.sign()
+			if err != nil {
+				return 0, err
+			}
+			return direction * magnitude, nil
+		}
+	}
+
+	direction, err := parser.sign()
+	if err != nil {
+		return 0, err
+	}
+	if parser.peek().kind == tokenCount {
+		magnitude, err := parser.count()
+		if err != nil {
+			return 0, err
+		}
+		return direction * magnitude, nil
+	}
+
+	charge := direction
+	for parser.peek().kind == tokenSign {
+		if next, _ := parser.sign(); next != direction {
+			parser.position--
+			return 0, parser.fail("repeated sign or end of formula")
+		}
+		charge += direction
+	}
+	return charge, nil
+}
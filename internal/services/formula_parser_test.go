@@ -0,0 +1,114 @@
+package services
+
+import (
+	"ChemistryPR/internal/models"
+	"errors"
+	"maps"
+	"testing"
+)
+
+func TestParseFormula(t *testing.T) {
+	tests := []struct {
+		formula  string
+		data     map[string]int
+		charge   int
+		state    string
+		isotopes map[models.Nuclide]int
+	}{
+		{formula: "H2O", data: map[string]int{"H": 2, "O": 1}},
+		{formula: "Ca3(PO4)2", data: map[string]int{"Ca": 3, "P": 2, "O": 8}},
+		{formula: "K4[Fe(CN)6]", data: map[string]int{"K": 4, "Fe": 1, "C": 6, "N": 6}},
+		{formula: "CuSO4·5H2O", data: map[string]int{"Cu": 1, "S": 1, "O": 9, "H": 10}},
+		{formula: "SO4^2-", data: map[string]int{"S": 1, "O": 4}, charge: -2},
+		{formula: "Fe+3", data: map[string]int{"Fe": 1}, charge: 3},
+		{formula: "Cu++", data: map[string]int{"Cu": 1}, charge: 2},
+		{formula: "e-", data: map[string]int{}, charge: -1},
+		{formula: "CO2(g)", data: map[string]int{"C": 1, "O": 2}, state: "g"},
+		{formula: "Fe^3+(aq)", data: map[string]int{"Fe": 1}, charge: 3, state: "aq"},
+		{formula: "Fe(OH)3(s)", data: map[string]int{"Fe": 1, "O": 3, "H": 3}, state: "s"},
+		{formula: "[13C]H4", data: map[string]int{"C": 1, "H": 4}, isotopes: map[models.Nuclide]int{{Symbol: "C", MassNumber: 13}: 1}},
+		{formula: "D2O", data: map[string]int{"H": 2, "O": 1}, isotopes: map[models.Nuclide]int{{Symbol: "H", MassNumber: 2}: 2}},
+	}
+
+	for _, test := range tests {
+		compound, err := parseFormula(test.formula, nil)
+		if err != nil {
+			t.Errorf("parseFormula(%q): %v", test.formula, err)
+			continue
+		}
+		if !maps.Equal(compound.Data, test.data) || compound.Charge != test.charge || compound.State != test.state {
+			t.Errorf("parseFormula(%q) = %v charge %d state %q, want %v charge %d state %q", test.formula,
+				compound.Data, compound.Charge, compound.State, test.data, test.charge, test.state)
+		}
+		if len(compound.Isotopes) > 0 || len(test.isotopes) > 0 {
+			if !maps.Equal(compound.Isotopes, test.isotopes) {
+				t.Errorf("parseFormula(%q) isotopes = %v, want %v", test.formula, compound.Isotopes, test.isotopes)
+			}
+		}
+	}
+}
+
+func TestParseFormulaErrors(t *testing.T) {
+	tests := []struct {
+		formula  string
+		column   int
+		expected string
+		found    string
+	}{
+		{formula: "", column: 1, expected: "element symbol or group", found: "end of formula"},
+		{formula: "h2o", column: 1, expected: "element symbol or group", found: `"h"`},
+		{formula: "H2O)", column: 4, expected: "element symbol, group, count, charge or end of formula", found: `")"`},
+		{formula: "Fe(OH", column: 6, expected: `")"`, found: "end of formula"},
+		{formula: "((H2O)", column: 7, expected: `")"`, found: "end of formula"},
+		{formula: "[13C", column: 5, expected: `"]"`, found: "end of formula"},
+		{formula: "CuSO4·", column: 7, expected: "element symbol or group", found: "end of formula"},
+		{formula: "Na+Cl", column: 4, expected: "state label or end of formula", found: `"Cl"`},
+		{formula: "H2O(x)", column: 5, expected: "element symbol or group", found: `"x"`},
+		{formula: "H2O^", column: 5, expected: `charge sign "+" or "-"`, found: "end of formula"},
+		{formula: "Fe^+-", column: 5, expected: "repeated sign or end of formula", found: `"-"`},
+		{formula: "e+", column: 2, expected: `electron charge "-"`, found: `"+"`},
+	}
+
+	for _, test := range tests {
+		_, err := parseFormula(test.formula, nil)
+		var parseError *ParseError
+		if !errors.As(err, &parseError) {
+			t.Errorf("parseFormula(%q) error = %v, want a *ParseError", test.formula, err)
+			continue
+		}
+		if parseError.Column != test.column || parseError.Expected != test.expected || parseError.Found != test.found {
+			t.Errorf("parseFormula(%q) error at column %d: expected %s, found %s; want column %d: expected %s, found %s",
+				test.formula, parseError.Column, parseError.Expected, parseError.Found, test.column, test.expected, test.found)
+		}
+	}
+}
+
+func TestParseCompoundAbbreviations(t *testing.T) {
+	tests := []struct {
+		formula  string
+		expanded string
+		data     map[string]int
+	}{
+		{formula: "PhCOOH", expanded: "C6H5COOH", data: map[string]int{"C": 7, "H": 6, "O": 2}},
+		{formula: "Et2O", expanded: "(C2H5)2O", data: map[string]int{"C": 4, "H": 10, "O": 1}},
+		{formula: "tBuOK", expanded: "C4H9OK", data: map[string]int{"C": 4, "H": 9, "O": 1, "K": 1}},
+		{formula: "AcOH", expanded: "C2H3O2H", data: map[string]int{"C": 2, "H": 4, "O": 2}},
+		{formula: "Pd(OAc)2", expanded: "Pd(C2H3O2)2", data: map[string]int{"Pd": 1, "C": 4, "H": 6, "O": 4}},
+		{formula: "nPrOH", expanded: "C3H7OH", data: map[string]int{"C": 3, "H": 8, "O": 1}},
+		{formula: "PrCl3", expanded: "PrCl3", data: map[string]int{"Pr": 1, "Cl": 3}},
+		{formula: "Pr2O3", expanded: "Pr2O3", data: map[string]int{"Pr": 2, "O": 3}},
+		{formula: "AcCl3", expanded: "AcCl3", data: map[string]int{"Ac": 1, "Cl": 3}},
+	}
+
+	service := testService(t)
+	for _, test := range tests {
+		compound, err := service.ParseCompound(test.formula)
+		if err != nil {
+			t.Errorf("ParseCompound(%q): %v", test.formula, err)
+			continue
+		}
+		if compound.Expanded != test.expanded || !maps.Equal(compound.Data, test.data) {
+			t.Errorf("ParseCompound(%q) = %q %v, want %q %v", test.formula, compound.Expanded, compound.Data, test.expanded, test.data)
+		}
+	}
+}
@@ -0,0 +1,506 @@
+package services
+
+import (
+	"ChemistryPR/internal/units"
+	"strings"
+)
+
+// GasService solves ideal-gas problems: the ideal gas law, the combined
+// gas law and Dalton's law of partial pressures. It embeds the
+// ChemicalService to weigh gases given by mass.
+type GasService struct {
+	ChemicalService
+}
+
+// GasConstant is the molar gas constant in kPa·L/(mol·K). It is exact by
+// definition and so does not limit significant figures.
+const GasConstant = 8.31446261815324
+
+// GasConditions are the temperature and pressure gas volumes are measured
+// at.
+type GasConditions struct {
+	Temperature units.Quantity // K for a bare number; that of STP when zero
+	Pressure    units.Quantity // kPa for a bare number; that of STP when zero
+}
+
+var (
+	// STP are the standard conditions of 0 °C and 101.325 kPa, under
+	// which a mole of ideal gas takes 22.414 L.
+	STP = GasConditions{
+		Temperature: units.Quantity{Value: 273.15, Unit: units.Kelvin, Figures: 5},
+		Pressure:    units.Quantity{Value: 101.325, Unit: units.Kilopascal, Figures: 6},
+	}
+	// SATP are the standard ambient conditions of 25 °C and 100 kPa, under
+	// which a mole of ideal gas takes 24.790 L.
+	SATP = GasConditions{
+		Temperature: units.Quantity{Value: 298.15, Unit: units.Kelvin, Figures: 5},
+		Pressure:    units.Quantity{Value: 100, Unit: units.Kilopascal, Figures: 5},
+	}
+)
+
+// MolarVolume returns the volume of a mole of ideal gas at the conditions,
+// known to the significant figures of the less precise of temperature and
+// pressure.
+func (conditions GasConditions) MolarVolume() (units.Quantity, error) {
+	if conditions.Temperature == (units.Quantity{}) {
+		conditions.Temperature = STP.Temperature
+	}
+	if conditions.Pressure == (units.Quantity{}) {
+		conditions.Pressure = STP.Pressure
+	}
+	temperature, err := readGasVariable("temperature", conditions.Temperature, units.Kelvin)
+	if err != nil {
+		return units.Quantity{}, err
+	}
+	pressure, err := readGasVariable("pressure", conditions.Pressure, units.Kilopascal)
+	if err != nil {
+		return units.Quantity{}, err
+	}
+	return units.Quantity{
+		Value:   GasConstant * temperature.value / pressure.value,
+		Unit:    units.LitrePerMole,
+		Figures: min(temperature.figures, pressure.figures),
+	}, nil
+}
+
+// GasLaw selects the problem a gas request solves.
+type GasLaw string
+
+const (
+	IdealGasLaw    GasLaw = "ideal"    // PV = nRT, solved for the one variable left out
+	CombinedGasLaw GasLaw = "combined" // P1V1/T1 = P2V2/T2 for a fixed amount of gas, solved for the one variable left out
+	DaltonLaw      GasLaw = "dalton"   // Partial pressures and mole fractions of a mixture
+)
+
+// GasState is the pressure, volume, amount and temperature of a gas. Bare
+// numbers are taken to be in kPa, L, mol and K; zero values are unknown.
+type GasState struct {
+	Pressure    units.Quantity
+	Volume      units.Quantity
+	Amount      units.Quantity // Amount in mol, or mass of GasInput.Formula
+	Temperature units.Quantity
+}
+
+// GasInput holds the data of a gas problem.
+type GasInput struct {
+	Law        GasLaw   // Problem to solve, IdealGasLaw when empty
+	Formula    string   // Gas the amounts are of, needed to give or get them as masses
+	State      GasState // The state for IdealGasLaw, the initial one for CombinedGasLaw and the whole mixture for DaltonLaw
+	Final      GasState // The final state for CombinedGasLaw; its Amount is not used
+	Components string   // Gases of the mixture for DaltonLaw, as "N2=78 kPa, O2=21 kPa" or "N2=28 g, O2=0.5 mol"
+}
+
+// GasResponse is the solution of a gas problem.
+type GasResponse struct {
+	Law         string
+	Formula     string
+	Solved      string             // Variable solved for, e.g. "V" or "T2"; empty for DaltonLaw
+	State       GasStateInfo       // The state with the solved variable filled in
+	Final       *GasStateInfo      // The final state, set for CombinedGasLaw
+	MolarMass   string             // Molar mass of Formula, empty without one
+	MolarVolume string             // Volume of a mole at the temperature and pressure of State, empty when either is unknown
+	Components  []GasComponentInfo // Gases of the mixture, set for DaltonLaw
+}
+
+// GasStateInfo is a gas state written out, every value in the unit it was
+// given in or, when solved for, in kPa, L, mol or K.
+type GasStateInfo struct {
+	Pressure    string
+	Volume      string
+	Amount      string
+	Mass        string // Mass of the amount, empty without a formula
+	Temperature string
+}
+
+// GasComponentInfo is one gas of a mixture.
+type GasComponentInfo struct {
+	Formula  string
+	Amount   string // Amount, empty when the components are given as pressures without a volume and temperature
+	Fraction string // Mole fraction
+	Pressure string // Partial pressure
+}
+
+// gasVariable is a variable of a gas problem in the base unit of its
+// dimension.
+type gasVariable struct {
+	value   float64
+	figures int
+	base    units.Unit // Unit of value
+	unit    units.Unit // Unit to write value in
+	set     bool       // The value is known
+}
+
+// readGasVariable converts quantity, in base when a bare number, to base.
+// The zero quantity is an unknown variable; other values must be positive.
+func readGasVariable(name string, quantity units.Quantity, base units.Unit) (gasVariable, error) {
+	variable := gasVariable{base: base, unit: base}
+	if quantity == (units.Quantity{}) {
+		return variable, nil
+	}
+	quantity = quantity.Default(base)
+	converted, err := quantity.In(base)
+	if err != nil {
+		return variable, inputErrorf("%s: %w", name, err)
+	}
+	if converted.Value <= 0 {
+		if base == units.Kelvin {
+			return variable, inputErrorf("%s must be above absolute zero, got %s", name, quantity)
+		}
+		return variable, inputErrorf("%s must be positive, got %s", name, quantity)
+	}
+	return gasVariable{value: converted.Value, figures: converted.Figures, base: base, unit: quantity.Unit, set: true}, nil
+}
+
+// format writes the variable in its unit, or "" when it is unknown.
+func (variable gasVariable) format(precision Precision) string {
+	if !variable.set {
+		return ""
+	}
+	quantity, err := units.Quantity{Value: variable.value, Unit: variable.base, Figures: variable.figures}.In(variable.unit)
+	if err != nil {
+		quantity = units.Quantity{Value: variable.value, Unit: variable.base, Figures: variable.figures}
+	}
+	return units.Join(formatQuantity(precision, quantity.Value, quantity.Figures), quantity.Unit)
+}
+
+// solved returns a variable found from others, known to the figures of the
+// least precise of them.
+func solved(value float64, base units.Unit, from ...gasVariable) gasVariable {
+	figures := maxDigits
+	for _, variable := range from {
+		figures = min(figures, variable.figures)
+	}
+	return gasVariable{value: value, figures: figures, base: base, unit: base, set: true}
+}
+
+// gasState is a GasState read into variables.
+type gasState struct {
+	pressure, volume, amount, temperature gasVariable
+}
+
+// GetResponse solves the gas problem of input.
+//
+// For IdealGasLaw three of pressure, volume, amount and temperature must
+// be given, and for CombinedGasLaw five of the initial and final pressure,
+// volume and temperature. For DaltonLaw the components are given either
+// all as partial pressures, whose sum is the total pressure, or all as
+// amounts, whose partial pressures come from the total pressure of State
+// or else from its volume and temperature.
+//
+// An amount may be given as a mass when input.Formula names the gas, whose
+// molar mass is computed as MolarMassService does; an amount solved for is
+// then written as a mass too. Results keep the significant figures of the
+// least precise value they come from; the gas constant is exact.
+func (service GasService) GetResponse(input GasInput, precision Precision) (GasResponse, error) {
+	response := GasResponse{Law: string(input.Law), Formula: strings.TrimSpace(input.Formula)}
+	if err := precision.Validate(); err != nil {
+		return response, err
+	}
+
+	var molar reactionSpecies
+	if response.Formula != "" {
+		var err error
+		if molar, err = service.gasSpecies(response.Formula); err != nil {
+			return response, err
+		}
+		response.MolarMass = units.Join(precision.FormatExact(molar.molarMass, molar.places), units.GramPerMole)
+	}
+	state, err := service.readGasState(input.State, molar, "")
+	if err != nil {
+		return response, err
+	}
+
+	switch input.Law {
+	case "", IdealGasLaw:
+		response.Law = string(IdealGasLaw)
+		response.Solved, err = solveIdealGas(&state)
+	case CombinedGasLaw:
+		var final gasState
+		if final, err = service.readGasState(input.Final, molar, "final "); err != nil {
+			return response, err
+		}
+		response.Solved, err = solveCombinedGas(&state, &final)
+		info := final.info(precision, molar)
+		response.Final = &info
+	case DaltonLaw:
+		response.Components, err = service.solveDalton(&state, input.Components, precision)
+	default:
+		return response, inputErrorf("unknown gas law %q", input.Law)
+	}
+	if err != nil {
+		return response, err
+	}
+
+	response.State = state.info(precision, molar)
+	if state.temperature.set && state.pressure.set {
+		molarVolume := solved(GasConstant*state.temperature.value/state.pressure.value, units.LitrePerMole, state.temperature, state.pressure)
+		response.MolarVolume = molarVolume.format(precision)
+	}
+	return response, nil
+}
+
+// gasSpecies parses formula and computes its molar mass.
+func (service GasService) gasSpecies(formula string) (reactionSpecies, error) {
+	compound, err := service.ParseCompound(formula)
+	if err != nil {
+		return reactionSpecies{}, err
+	}
+	weight, places, err := MolarMassService{service.ChemicalService}.molarMass(compound)
+	if err != nil {
+		return reactionSpecies{}, err
+	}
+	value, _ := weight.Float64()
+	return reactionSpecies{
+		formula:      formula,
+		molarMass:    weight,
+		places:       places,
+		molarValue:   value,
+		molarFigures: significantPlaces(value, places),
+	}, nil
+}
+
+// readGasState reads the variables of state. A mass is converted to moles
+// with the molar mass of the gas. prefix names the state in errors.
+func (service GasService) readGasState(state GasState, gas reactionSpecies, prefix string) (gasState, error) {
+	var result gasState
+	var err error
+	if result.pressure, err = readGasVariable(prefix+"pressure", state.Pressure, units.Kilopascal); err != nil {
+		return result, err
+	}
+	if result.volume, err = readGasVariable(prefix+"volume", state.Volume, units.Litre); err != nil {
+		return result, err
+	}
+	if result.temperature, err = readGasVariable(prefix+"temperature", state.Temperature, units.Kelvin); err != nil {
+		return result, err
+	}
+	amount := state.Amount
+	if amount.Unit.Dimension == units.Mass {
+		if gas.formula == "" {
+			return result, inputErrorf("%samount %s is a mass; name the gas to convert it to moles", prefix, amount)
+		}
+		moles, figures, err := gas.moles(amount, units.Quantity{})
+		if err != nil {
+			return result, err
+		}
+		amount = units.Quantity{Value: moles, Unit: units.Mole, Figures: figures}
+	}
+	if result.amount, err = readGasVariable(prefix+"amount", amount, units.Mole); err != nil {
+		return result, err
+	}
+	result.amount.unit = units.Mole
+	return result, nil
+}
+
+// info writes the state out, with the mass of its amount when the gas is
+// known.
+func (state gasState) info(precision Precision, gas reactionSpecies) GasStateInfo {
+	info := GasStateInfo{
+		Pressure:    state.pressure.format(precision),
+		Volume:      state.volume.format(precision),
+		Amount:      state.amount.format(precision),
+		Temperature: state.temperature.format(precision),
+	}
+	if gas.formula != "" && state.amount.set {
+		info.Mass = gas.grams(precision, state.amount.value, state.amount.figures)
+	}
+	return info
+}
+
+// solveIdealGas fills in the one unknown variable of state from PV = nRT
+// and returns its symbol.
+func solveIdealGas(state *gasState) (string, error) {
+	p, v, n, t := state.pressure, state.volume, state.amount, state.temperature
+	unknown := 0
+	for _, variable := range []gasVariable{p, v, n, t} {
+		if !variable.set {
+			unknown++
+		}
+	}
+	if unknown != 1 {
+		return "", inputErrorf("give exactly three of pressure, volume, amount and temperature, %d are missing", unknown)
+	}
+	switch {
+	case !p.set:
+		state.pressure = solved(n.value*GasConstant*t.value/v.value, units.Kilopascal, v, n, t)
+		return "P", nil
+	case !v.set:
+		state.volume = solved(n.value*GasConstant*t.value/p.value, units.Litre, p, n, t)
+		return "V", nil
+	case !n.set:
+		state.amount = solved(p.value*v.value/(GasConstant*t.value), units.Mole, p, v, t)
+		return "n", nil
+	default:
+		state.temperature = solved(p.value*v.value/(n.value*GasConstant), units.Kelvin, p, v, n)
+		return "T", nil
+	}
+}
+
+// solveCombinedGas fills in the one unknown of the pressures, volumes and
+// temperatures of initial and final from P1·V1·T2 = P2·V2·T1 and returns
+// its symbol. The solved variable is written in the unit of its
+// counterpart in the other state.
+func solveCombinedGas(initial, final *gasState) (string, error) {
+	// Each side of P1·V1·T2 = P2·V2·T1, with the counterpart of every
+	// variable at the same place on the other side.
+	left := []*gasVariable{&initial.pressure, &initial.volume, &final.temperature}
+	right := []*gasVariable{&final.pressure, &final.volume, &initial.temperature}
+	symbols := [][]string{{"P1", "V1", "T2"}, {"P2", "V2", "T1"}}
+
+	unknowns, side, place := 0, 0, 0
+	for s, variables := range [][]*gasVariable{left, right} {
+		for i, variable := range variables {
+			if !variable.set {
+				unknowns, side, place = unknowns+1, s, i
+			}
+		}
+	}
+	if unknowns != 1 {
+		return "", inputErrorf("give exactly five of the initial and final pressure, volume and temperature, %d are missing", unknowns)
+	}
+	known, other := left, right
+	if side == 1 {
+		known, other = right, left
+	}
+	value := 1.0
+	var from []gasVariable
+	for i := range known {
+		value *= other[i].value
+		from = append(from, *other[i])
+		if i != place {
+			value /= known[i].value
+			from = append(from, *known[i])
+		}
+	}
+	variable := solved(value, known[place].base, from...)
+	variable.unit = other[place].unit
+	*known[place] = variable
+	return symbols[side][place], nil
+}
+
+// solveDalton reads the components of a mixture and computes their mole
+// fractions and partial pressures, filling in the total pressure and
+// amount of state.
+func (service GasService) solveDalton(state *gasState, components string, precision Precision) ([]GasComponentInfo, error) {
+	type component struct {
+		formula  string
+		quantity units.Quantity
+	}
+	var entries []component
+	separator := ","
+	if strings.Contains(components, ";") {
+		separator = ";"
+	}
+	for _, item := range strings.Split(components, separator) {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		equals := strings.LastIndex(item, "=")
+		if equals < 0 || strings.TrimSpace(item[:equals]) == "" {
+			return nil, inputErrorf("component %q must be written as gas=amount, e.g. \"O2=21 kPa\"", strings.TrimSpace(item))
+		}
+		quantity, err := units.Parse(item[equals+1:])
+		if err != nil {
+			return nil, inputErrorf("component %s: %w", strings.TrimSpace(item[:equals]), err)
+		}
+		entries = append(entries, component{formula: strings.TrimSpace(item[:equals]), quantity: quantity})
+	}
+	if len(entries) == 0 {
+		return nil, inputErrorf("no components of the mixture given")
+	}
+
+	pressures := entries[0].quantity.Unit.Dimension == units.Pressure
+	if pressures && state.pressure.set {
+		return nil, inputErrorf("the total pressure is the sum of the partial pressures and must not be given")
+	}
+	if !pressures && state.amount.set {
+		return nil, inputErrorf("the total amount is the sum of the amounts of the components and must not be given")
+	}
+	amounts := make([]gasVariable, len(entries))
+	partials := make([]gasVariable, len(entries))
+	for i, entry := range entries {
+		var err error
+		if (entry.quantity.Unit.Dimension == units.Pressure) != pressures {
+			return nil, inputErrorf("give the components either all as pressures or all as amounts, %s is not", entry.formula)
+		}
+		if pressures {
+			partials[i], err = readGasVariable(entry.formula, entry.quantity, units.Kilopascal)
+		} else {
+			quantity := entry.quantity
+			if quantity.Unit.Dimension == units.Mass {
+				gas, err := service.gasSpecies(entry.formula)
+				if err != nil {
+					return nil, err
+				}
+				moles, figures, err := gas.moles(quantity, units.Quantity{})
+				if err != nil {
+					return nil, err
+				}
+				quantity = units.Quantity{Value: moles, Unit: units.Mole, Figures: figures}
+			}
+			amounts[i], err = readGasVariable(entry.formula, quantity, units.Mole)
+			amounts[i].unit = units.Mole
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if pressures {
+		state.pressure = sumGasVariables(partials, units.Kilopascal)
+		state.pressure.unit = partials[0].unit
+		if state.volume.set && state.temperature.set {
+			for i := range amounts {
+				amounts[i] = solved(partials[i].value*state.volume.value/(GasConstant*state.temperature.value), units.Mole,
+					partials[i], state.volume, state.temperature)
+			}
+			state.amount = sumGasVariables(amounts, units.Mole)
+		}
+	} else {
+		state.amount = sumGasVariables(amounts, units.Mole)
+		switch {
+		case state.pressure.set:
+			for i := range partials {
+				partials[i] = solved(amounts[i].value/state.amount.value*state.pressure.value, units.Kilopascal, amounts[i], state.amount, state.pressure)
+				partials[i].unit = state.pressure.unit
+			}
+		case state.volume.set && state.temperature.set:
+			for i := range partials {
+				partials[i] = solved(amounts[i].value*GasConstant*state.temperature.value/state.volume.value, units.Kilopascal,
+					amounts[i], state.volume, state.temperature)
+			}
+			state.pressure = sumGasVariables(partials, units.Kilopascal)
+		default:
+			return nil, inputErrorf("give the total pressure, or the volume and temperature, of the mixture")
+		}
+	}
+
+	info := make([]GasComponentInfo, len(entries))
+	for i, entry := range entries {
+		fraction := solved(partials[i].value/state.pressure.value, units.None, partials[i], state.pressure)
+		if !pressures {
+			fraction = solved(amounts[i].value/state.amount.value, units.None, amounts[i], state.amount)
+		}
+		info[i] = GasComponentInfo{
+			Formula:  entry.formula,
+			Amount:   amounts[i].format(precision),
+			Fraction: fraction.format(precision),
+			Pressure: partials[i].format(precision),
+		}
+	}
+	return info, nil
+}
+
+// sumGasVariables adds variables of one dimension, keeping the decimal
+// places of the least precise of them.
+func sumGasVariables(variables []gasVariable, base units.Unit) gasVariable {
+	total := gasVariable{base: base, unit: base, set: true}
+	places := 0
+	for i, variable := range variables {
+		total.value += variable.value
+		if p := decimalPlaces(variable.value, variable.figures); i == 0 || p < places {
+			places = p
+		}
+	}
+	total.figures = significantPlaces(total.value, places)
+	return total
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"testing"
+)
+
+// parseGasState parses the pressure, volume, amount and temperature of a gas
+// state, an empty string leaving the variable unknown.
+func parseGasState(t *testing.T, pressure, volume, amount, temperature string) GasState {
+	t.Helper()
+	return GasState{
+		Pressure:    parseQuantity(t, pressure),
+		Volume:      parseQuantity(t, volume),
+		Amount:      parseQuantity(t, amount),
+		Temperature: parseQuantity(t, temperature),
+	}
+}
+
+func TestGasLaws(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  GasInput
+		solved string
+		state  GasStateInfo
+		final  GasStateInfo
+		err    bool
+	}{
+		{
+			name:   "ideal volume at STP",
+			input:  GasInput{State: parseGasState(t, "101.325 kPa", "", "1.00 mol", "273.15 K")},
+			solved: "V",
+			state:  GasStateInfo{Pressure: "101.325 kPa", Volume: "22.4 L", Amount: "1.00 mol", Temperature: "273.15 K"},
+		},
+		{
+			name:   "ideal amount as a mass",
+			input:  GasInput{Formula: "O2", State: parseGasState(t, "1.00 atm", "24.5 L", "", "25.0 °C")},
+			solved: "n",
+			state:  GasStateInfo{Pressure: "1.00 atm", Volume: "24.5 L", Amount: "1.00 mol", Mass: "32.0 g", Temperature: "25.0 °C"},
+		},
+		{
+			name:   "ideal temperature",
+			input:  GasInput{State: parseGasState(t, "200 kPa", "10.0 L", "1.00 mol", "")},
+			solved: "T",
+			state:  GasStateInfo{Pressure: "200 kPa", Volume: "10.0 L", Amount: "1.00 mol", Temperature: "241 K"},
+		},
+		{
+			name: "combined final volume",
+			input: GasInput{
+				Law:   CombinedGasLaw,
+				State: parseGasState(t, "100 kPa", "2.00 L", "", "300 K"),
+				Final: parseGasState(t, "200 kPa", "", "", "600 K"),
+			},
+			solved: "V2",
+			state:  GasStateInfo{Pressure: "100 kPa", Volume: "2.00 L", Temperature: "300 K"},
+			final:  GasStateInfo{Pressure: "200 kPa", Volume: "2.00 L", Temperature: "600 K"},
+		},
+		{
+			name: "combined final pressure",
+			input: GasInput{
+				Law:   CombinedGasLaw,
+				State: parseGasState(t, "1.00 atm", "5.00 L", "", "273 K"),
+				Final: parseGasState(t, "", "2.50 L", "", "273 K"),
+			},
+			solved: "P2",
+			state:  GasStateInfo{Pressure: "1.00 atm", Volume: "5.00 L", Temperature: "273 K"},
+			final:  GasStateInfo{Pressure: "2.00 atm", Volume: "2.50 L", Temperature: "273 K"},
+		},
+		{name: "ideal with two unknowns", input: GasInput{State: parseGasState(t, "100 kPa", "", "", "300 K")}, err: true},
+		{name: "ideal with nothing unknown", input: GasInput{State: parseGasState(t, "100 kPa", "1 L", "1 mol", "300 K")}, err: true},
+		{name: "below absolute zero", input: GasInput{State: parseGasState(t, "100 kPa", "", "1 mol", "-300 °C")}, err: true},
+		{name: "mass without a formula", input: GasInput{State: parseGasState(t, "100 kPa", "", "32 g", "300 K")}, err: true},
+		{name: "unknown law", input: GasInput{Law: "boyle"}, err: true},
+	}
+
+	service := GasService{testService(t)}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := service.GetResponse(test.input, Precision{})
+			if test.err {
+				if err == nil {
+					t.Fatalf("GetResponse succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetResponse: %v", err)
+			}
+			if response.Solved != test.solved {
+				t.Errorf("Solved = %q, want %q", response.Solved, test.solved)
+			}
+			if response.State != test.state {
+				t.Errorf("State = %+v, want %+v", response.State, test.state)
+			}
+			if response.Final != nil && *response.Final != test.final {
+				t.Errorf("Final = %+v, want %+v", *response.Final, test.final)
+			}
+		})
+	}
+}
+
+func TestDaltonLaw(t *testing.T) {
+	tests := []struct {
+		name       string
+		state      GasState
+		components string
+		pressure   string // Total pressure
+		fractions  []string
+		partials   []string
+		err        bool
+	}{
+		{
+			name: "partial pressures", components: "N2=78.1 kPa, O2=20.9 kPa, Ar=1.0 kPa",
+			pressure:  "100.0 kPa",
+			fractions: []string{"0.781", "0.209", "0.010"},
+			partials:  []string{"78.1 kPa", "20.9 kPa", "1.0 kPa"},
+		},
+		{
+			name: "amounts at a total pressure", state: parseGasState(t, "1.00 atm", "", "", ""),
+			components: "N2=28.0 g, O2=0.500 mol",
+			pressure:   "1.00 atm",
+			fractions:  []string{"0.667", "0.333"},
+			partials:   []string{"0.667 atm", "0.333 atm"},
+		},
+		{name: "total pressure given with partial pressures", state: parseGasState(t, "100 kPa", "", "", ""), components: "N2=78 kPa, O2=21 kPa", err: true},
+		{name: "pressures mixed with amounts", components: "N2=78 kPa, O2=1 mol", err: true},
+		{name: "no components", err: true},
+	}
+
+	service := GasService{testService(t)}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := service.GetResponse(GasInput{Law: DaltonLaw, State: test.state, Components: test.components}, Precision{})
+			if test.err {
+				if err == nil {
+					t.Fatalf("GetResponse succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetResponse: %v", err)
+			}
+			if response.State.Pressure != test.pressure {
+				t.Errorf("total pressure = %q, want %q", response.State.Pressure, test.pressure)
+			}
+			for i, component := range response.Components {
+				if component.Fraction != test.fractions[i] || component.Pressure != test.partials[i] {
+					t.Errorf("%s = %q at %q, want %q at %q", component.Formula, component.Fraction, component.Pressure,
+						test.fractions[i], test.partials[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMolarVolume(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions GasConditions
+		value      float64
+		figures    int
+	}{
+		{name: "STP by default", value: 22.414, figures: 5},
+		{name: "SATP", conditions: SATP, value: 24.790, figures: 5},
+		{name: "given", conditions: GasConditions{Temperature: parseQuantity(t, "25.0 °C")}, value: 24.465, figures: 4},
+	}
+
+	for _, test := range tests {
+		volume, err := test.conditions.MolarVolume()
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if formatted := (Precision{}).Format(volume.Value, volume.Figures); formatted != (Precision{}).Format(test.value, test.figures) {
+			t.Errorf("%s: molar volume = %s L/mol, want %v", test.name, formatted, test.value)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package services
+
+// Ions of salts, acids and bases as the calculators split them: the
+// acid-base calculator to find the acid or base a salt is a form of, and
+// the redox mode to take the half-reactions of a molecular equation.
+
+// spectatorCations are the alkali and alkaline-earth metal cations, which
+// take no part in proton or electron transfer, with their charges.
+var spectatorCations = []struct {
+	symbol string
+	charge int
+}{{"Li", 1}, {"Na", 1}, {"K", 1}, {"Rb", 1}, {"Cs", 1}, {"Mg", 2}, {"Ca", 2}, {"Sr", 2}, {"Ba", 2}}
+
+// removeCounts returns counts less times units of part, or nil when counts
+// hold fewer.
+func removeCounts(counts, part map[string]int, times int) map[string]int {
+	rest := make(map[string]int, len(counts))
+	for symbol, count := range counts {
+		rest[symbol] = count
+	}
+	for symbol, count := range part {
+		rest[symbol] -= count * times
+		if rest[symbol] < 0 {
+			return nil
+		}
+		if rest[symbol] == 0 {
+			delete(rest, symbol)
+		}
+	}
+	return rest
+}
+
+// divideCounts returns counts divided by divisor when every count is a
+// multiple of it.
+func divideCounts(counts map[string]int, divisor int) (map[string]int, bool) {
+	divided := make(map[string]int, len(counts))
+	for symbol, count := range counts {
+		if count%divisor != 0 {
+			return nil, false
+		}
+		divided[symbol] = count / divisor
+	}
+	return divided, true
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"ChemistryPR/internal/models"
+	"fmt"
+	"sort"
+)
+
+// IsotopePattern is the predicted mass spectrum of a formula: the
+// monoisotopic mass and the peaks of its isotopic distribution.
+type IsotopePattern struct {
+	MonoisotopicMass float64       // Sum of the exact masses of the most abundant isotope of every unlabelled atom and of the labelled nuclides
+	Peaks            []IsotopePeak // Peaks by increasing mass, those below minPeakIntensity left out
+}
+
+// IsotopePeak is one nominal-mass peak of an isotope pattern.
+type IsotopePeak struct {
+	Label     string  // Offset from the monoisotopic peak in nucleons, e.g. "M", "M+1" or "M-2"
+	Mass      float64 // Abundance-weighted mean exact mass of the isotopologues in the peak
+	Intensity float64 // Intensity in percent of the tallest peak
+}
+
+// minPeakIntensity is the relative intensity, in percent, below which
+// peaks are left out of a pattern.
+const minPeakIntensity = 0.1
+
+// prunedProbability is the probability below which isotopologues are
+// dropped while the distribution is built, relative to the largest one.
+const prunedProbability = 1e-12
+
+// massPeak is the total probability of the isotopologues with the same
+// nucleon count and their probability-weighted mass.
+type massPeak struct {
+	probability  float64
+	weightedMass float64
+}
+
+// massDistribution maps nucleon counts to the peaks of a distribution.
+type massDistribution map[int]massPeak
+
+// convolve returns the distribution of a molecule made of one part drawn
+// from a and one drawn from b.
+func (a massDistribution) convolve(b massDistribution) massDistribution {
+	result := make(massDistribution, len(a)+len(b))
+	largest := 0.0
+	for na, pa := range a {
+		for nb, pb := range b {
+			peak := result[na+nb]
+			peak.probability += pa.probability * pb.probability
+			peak.weightedMass += pa.weightedMass*pb.probability + pb.weightedMass*pa.probability
+			result[na+nb] = peak
+			largest = max(largest, peak.probability)
+		}
+	}
+	for n, peak := range result {
+		if peak.probability < largest*prunedProbability {
+			delete(result, n)
+		}
+	}
+	return result
+}
+
+// power returns the distribution of count independent draws from d.
+func (d massDistribution) power(count int) massDistribution {
+	result := massDistribution{0: {probability: 1}}
+	for ; count > 0; count >>= 1 {
+		if count&1 == 1 {
+			result = result.convolve(d)
+		}
+		if count > 1 {
+			d = d.convolve(d)
+		}
+	}
+	return result
+}
+
+// isotopePattern computes the monoisotopic mass and the isotope pattern of
+// compound. Unlabelled atoms follow the natural abundances of the isotopes
+// table, labelled atoms are always their nuclide.
+func (service MolarMassService) isotopePattern(compound models.Compound, isotopes map[models.Nuclide]models.Isotope) (*IsotopePattern, error) {
+	pattern := &IsotopePattern{}
+	distribution := massDistribution{0: {probability: 1}}
+	monoisotopicNucleons := 0
+
+	for symbol, count := range compound.Data {
+		for nuclide, labelled := range compound.Isotopes {
+			if nuclide.Symbol == symbol {
+				count -= labelled
+			}
+		}
+		if count == 0 {
+			continue
+		}
+
+		natural, err := service.Store.GetIsotopes(symbol)
+		if err != nil {
+			return nil, err
+		}
+		element := make(massDistribution)
+		var mostAbundant models.Isotope
+		for _, isotope := range natural {
+			if isotope.Abundance == 0 {
+				continue
+			}
+			element[isotope.MassNumber] = massPeak{isotope.Abundance, isotope.Abundance * isotope.ExactMass}
+			if isotope.Abundance > mostAbundant.Abundance {
+				mostAbundant = isotope
+			}
+		}
+		if len(element) == 0 {
+			return nil, inputErrorf("%w: no natural isotopes of %s", ErrUnknownIsotope, symbol)
+		}
+
+		pattern.MonoisotopicMass += mostAbundant.ExactMass * float64(count)
+		monoisotopicNucleons += mostAbundant.MassNumber * count
+		distribution = distribution.convolve(element.power(count))
+	}
+
+	for nuclide, count := range compound.Isotopes {
+		isotope := isotopes[nuclide]
+		pattern.MonoisotopicMass += isotope.ExactMass * float64(count)
+		monoisotopicNucleons += nuclide.MassNumber * count
+		label := massDistribution{nuclide.MassNumber: {probability: 1, weightedMass: isotope.ExactMass}}
+		distribution = distribution.convolve(label.power(count))
+	}
+
+	tallest := 0.0
+	for _, peak := range distribution {
+		tallest = max(tallest, peak.probability)
+	}
+	for nucleons, peak := range distribution {
+		intensity := peak.probability / tallest * 100
+		if intensity < minPeakIntensity {
+			continue
+		}
+		label := "M"
+		if offset := nucleons - monoisotopicNucleons; offset != 0 {
+			label = fmt.Sprintf("M%+d", offset)
+		}
+		pattern.Peaks = append(pattern.Peaks, IsotopePeak{
+			Label:     label,
+			Mass:      peak.weightedMass / peak.probability,
+			Intensity: intensity,
+		})
+	}
+	sort.Slice(pattern.Peaks, func(i, j int) bool { return pattern.Peaks[i].Mass < pattern.Peaks[j].Mass })
+	return pattern, nil
+}
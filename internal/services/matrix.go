@@ -0,0 +1,198 @@
+package services
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// ratMatrix is a dense matrix of exact rational numbers. It is used by the
+// balancer so that row reduction never loses precision the way float64 does.
+type ratMatrix [][]*big.Rat
+
+// newRatMatrix allocates a rows x cols matrix filled with zeros.
+func newRatMatrix(rows, cols int) ratMatrix {
+	matrix := make(ratMatrix, rows)
+	for i := range matrix {
+		matrix[i] = make([]*big.Rat, cols)
+		for j := range matrix[i] {
+			matrix[i][j] = new(big.Rat)
+		}
+	}
+	return matrix
+}
+
+// cols returns the number of columns of the matrix.
+func (matrix ratMatrix) cols() int {
+	if len(matrix) == 0 {
+		return 0
+	}
+	return len(matrix[0])
+}
+
+// rowTracer is told about every elementary row operation performed by rref,
+// after the operation has been applied. Rows are numbered from 1.
+type rowTracer func(operation string)
+
+// rref brings the matrix to reduced row echelon form in place and returns
+// the indexes of the pivot columns. trace may be nil.
+func (matrix ratMatrix) rref(trace rowTracer) []int {
+	if trace == nil {
+		trace = func(string) {}
+	}
+	pivots := make([]int, 0)
+	row := 0
+	for col := 0; col < matrix.cols() && row < len(matrix); col++ {
+		pivot := -1
+		for i := row; i < len(matrix); i++ {
+			if matrix[i][col].Sign() != 0 {
+				pivot = i
+				break
+			}
+		}
+		if pivot == -1 {
+			continue
+		}
+		if pivot != row {
+			matrix[row], matrix[pivot] = matrix[pivot], matrix[row]
+			trace(fmt.Sprintf("R%d ↔ R%d", row+1, pivot+1))
+		}
+
+		inverse := new(big.Rat).Inv(matrix[row][col])
+		if inverse.Cmp(big.NewRat(1, 1)) != 0 {
+			for j := range matrix[row] {
+				matrix[row][j].Mul(matrix[row][j], inverse)
+			}
+			trace(fmt.Sprintf("R%d ← %s·R%d", row+1, inverse.RatString(), row+1))
+		}
+
+		for i := range matrix {
+			if i == row || matrix[i][col].Sign() == 0 {
+				continue
+			}
+			factor := new(big.Rat).Set(matrix[i][col])
+			for j := range matrix[i] {
+				matrix[i][j].Sub(matrix[i][j], new(big.Rat).Mul(factor, matrix[row][j]))
+			}
+			if factor.Sign() > 0 {
+				trace(fmt.Sprintf("R%d ← R%d − %s·R%d", i+1, i+1, factor.RatString(), row+1))
+			} else {
+				trace(fmt.Sprintf("R%d ← R%d + %s·R%d", i+1, i+1, new(big.Rat).Neg(factor).RatString(), row+1))
+			}
+		}
+
+		pivots = append(pivots, col)
+		row++
+	}
+	return pivots
+}
+
+// nullspace returns a basis of the right nullspace of the matrix, one vector
+// per free column, and the free columns in the same order. The matrix is
+// reduced in place and trace, which may be nil, sees every row operation.
+func (matrix ratMatrix) nullspace(trace rowTracer) ([][]*big.Rat, []int) {
+	pivots := matrix.rref(trace)
+	isPivot := make(map[int]int, len(pivots))
+	for row, col := range pivots {
+		isPivot[col] = row
+	}
+
+	basis := make([][]*big.Rat, 0)
+	frees := make([]int, 0)
+	for free := 0; free < matrix.cols(); free++ {
+		if _, ok := isPivot[free]; ok {
+			continue
+		}
+		frees = append(frees, free)
+		vector := make([]*big.Rat, matrix.cols())
+		for j := range vector {
+			vector[j] = new(big.Rat)
+		}
+		vector[free].SetInt64(1)
+		for col, row := range isPivot {
+			vector[col].Neg(matrix[row][free])
+		}
+		basis = append(basis, vector)
+	}
+	return basis, frees
+}
+
+// strings renders every entry of the matrix as a reduced fraction.
+func (matrix ratMatrix) strings() [][]string {
+	rendered := make([][]string, len(matrix))
+	for i, row := range matrix {
+		rendered[i] = make([]string, len(row))
+		for j, value := range row {
+			rendered[i][j] = value.RatString()
+		}
+	}
+	return rendered
+}
+
+// scaleToIntegers multiplies a rational vector by the least common multiple
+// of its denominators, giving an integer vector with the same direction.
+func scaleToIntegers(vector []*big.Rat) []*big.Int {
+	lcm := big.NewInt(1)
+	for _, value := range vector {
+		denominator := value.Denom()
+		gcd := new(big.Int).GCD(nil, nil, lcm, denominator)
+		lcm.Mul(lcm, new(big.Int).Quo(denominator, gcd))
+	}
+
+	result := make([]*big.Int, len(vector))
+	for i, value := range vector {
+		scaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(lcm))
+		result[i] = new(big.Int).Set(scaled.Num())
+	}
+	return result
+}
+
+// integerVector scales a rational vector to integers and divides the result
+// by the greatest common divisor of its entries, giving the smallest integer
+// vector with the same direction.
+func integerVector(vector []*big.Rat) []*big.Int {
+	result := scaleToIntegers(vector)
+	gcd := new(big.Int)
+	for _, value := range result {
+		gcd.GCD(nil, nil, gcd, new(big.Int).Abs(value))
+	}
+
+	if gcd.Sign() != 0 {
+		for _, value := range result {
+			value.Quo(value, gcd)
+		}
+	}
+	return result
+}
+
+// augmented returns a copy of the matrix with an extra zero column that
+// serves as the right-hand side of a linear system.
+func (matrix ratMatrix) augmented() ratMatrix {
+	augmented := newRatMatrix(len(matrix), matrix.cols()+1)
+	for i := range matrix {
+		for j := range matrix[i] {
+			augmented[i][j].Set(matrix[i][j])
+		}
+	}
+	return augmented
+}
+
+// solveAugmented reduces an augmented matrix whose last column is the
+// right-hand side and returns the unique solution. consistent is false when
+// the system has no solution; a nil solution with consistent set means that
+// some variables are free.
+func (matrix ratMatrix) solveAugmented() (solution []*big.Rat, consistent bool) {
+	variables := matrix.cols() - 1
+	pivots := matrix.rref(nil)
+	if len(pivots) > 0 && pivots[len(pivots)-1] == variables {
+		return nil, false
+	}
+	if len(pivots) < variables {
+		return nil, true
+	}
+
+	solution = make([]*big.Rat, variables)
+	for row, col := range pivots {
+		solution[col] = new(big.Rat).Set(matrix[row][variables])
+	}
+	return solution, true
+}
@@ -2,7 +2,12 @@ package services
 
 import (
 	"ChemistryPR/internal/models"
+	"ChemistryPR/internal/units"
 	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
 )
 
 // MolarMassService is a service that provides functionalities
@@ -23,15 +28,50 @@ type MolarMassElementInfo struct {
 	WeightInCompound string // Weight of the element in the compound
 	AtomsCount       string // Number of atoms of the element in the compound
 	WeightPercent    string // Weight percentage of the element in the compound
+	Uncertainty      string // Uncertainty of WeightInCompound from that of the atomic weight, e.g. "0.0004 g/mol"; empty when the table has none
+	Interval         string // Range of WeightInCompound over the IUPAC interval weight, e.g. "[2.01568, 2.01622] g/mol"; empty for other elements and fully labelled ones
+	Isotopes         string // Labelled atoms of the element and their exact masses, e.g. "2H: 2 × 2.014102"; empty when none
+}
+
+// MolarMassMode selects what a molar mass request computes.
+type MolarMassMode string
+
+const (
+	AverageMassMode MolarMassMode = "average"  // Molar mass from standard atomic weights
+	IsotopicMode    MolarMassMode = "isotopic" // Average molar mass plus the monoisotopic mass and the isotope pattern
+)
+
+// MolarMassOptions holds the optional parameters of a molar mass request.
+// The zero value computes the average molar mass.
+type MolarMassOptions struct {
+	Mode      MolarMassMode // Calculation mode, AverageMassMode when empty
+	Precision Precision     // Rounding of the weights and percentages
 }
 
 // MolarMassResponse encapsulates the result of a molar mass
 // calculation, including the total general weight and a list
 // of element information.
 type MolarMassResponse struct {
-	Formula  string
-	Total    float64                // Total weight of the compound
-	Elements []MolarMassElementInfo // Slice of element information
+	Formula     string
+	Expanded    string                 // Formula with functional-group abbreviations written out, empty when it has none
+	Total       string                 // Total weight of the compound
+	Uncertainty string                 // Combined uncertainty of Total, the elements' uncertainties added in quadrature
+	Interval    string                 // Range of Total over the IUPAC interval weights, empty when no element has one
+	Elements    []MolarMassElementInfo // Slice of element information
+	Hydrate     *MolarMassHydrateInfo  // Anhydrous part and water of crystallization, set for hydrates and adducts
+	Isotopic    *IsotopePattern        // Monoisotopic mass and isotope pattern, set in IsotopicMode
+	Warnings    []string               // Likely typos in the formula, such as "CI" for "Cl"
+}
+
+// MolarMassHydrateInfo splits the weight of a hydrate or adduct
+// such as "CuSO4·5H2O" into its anhydrous part and its water of
+// crystallization.
+type MolarMassHydrateInfo struct {
+	Anhydrous       string // Formula of the parts other than water, e.g. "CuSO4"
+	AnhydrousWeight string // Weight of the anhydrous part
+	WaterMolecules  string // Molecules of water of crystallization per formula unit
+	WaterWeight     string // Weight of the water of crystallization
+	WaterPercent    string // Weight percentage of the water of crystallization
 }
 
 // GetResponse processes the provided requestedData string to
@@ -44,25 +84,54 @@ type MolarMassResponse struct {
 // Parameters:
 //   - requestedData: A string representing the chemical compound
 //     for which the molar mass is to be calculated.
+//   - options: The calculation mode and the rounding of the
+//     results. In IsotopicMode the response also holds the
+//     monoisotopic mass and the predicted isotope pattern of the
+//     mass spectrum, computed from the natural abundances of the
+//     isotopes table.
 //
 // Returns:
 //   - MolarMassResponse: The response containing general weight
 //     and detailed information about the elements in the compound.
-//   - error: An error indicator, nil if no errors occurred.
-func (service MolarMassService) GetResponse(requestedData string) (MolarMassResponse, error) {
+//   - error: An error indicator, nil if no errors occurred. Symbols
+//     missing from the periodic table are reported together as an
+//     *UnknownElementError.
+func (service MolarMassService) GetResponse(requestedData string, options MolarMassOptions) (MolarMassResponse, error) {
 	response := MolarMassResponse{}
 	response.Elements = nil
+	if err := options.Precision.Validate(); err != nil {
+		return response, err
+	}
 	compound, err := service.ParseCompound(requestedData)
 	if err != nil {
 		return response, err
 	}
-	elements, err := service.Store.GetElements(compound)
+	elements, err := service.GetElements(compound)
+	if err != nil {
+		return response, err
+	}
+	isotopes, err := service.GetIsotopes(compound)
 	if err != nil {
 		return response, err
 	}
 
-	response = service.ComputeData(compound, elements)
+	response = service.ComputeData(compound, elements, isotopes, options.Precision)
 	response.Formula = requestedData
+	response.Warnings = confusedSymbols(requestedData)
+	if compound.Expanded != compound.Formula {
+		response.Expanded = compound.Expanded
+	}
+
+	switch options.Mode {
+	case "", AverageMassMode:
+	case IsotopicMode:
+		response.Isotopic, err = service.isotopePattern(compound, isotopes)
+		if err != nil {
+			return response, err
+		}
+	default:
+		return response, inputErrorf("unknown molar mass mode %q", options.Mode)
+	}
 
 	return response, nil
 }
@@ -76,34 +145,248 @@ func (service MolarMassService) GetResponse(requestedData string) (MolarMassResp
 //   - compound: The chemical compound whose molar mass needs
 //     to be calculated.
 //   - elements: A slice of elements involved in the compound.
+//   - isotopes: The nuclides of the labelled atoms of the compound.
+//     Labelled atoms weigh the exact mass of their nuclide, all
+//     others the standard atomic weight of their element.
+//   - precision: The rounding of the weights and percentages.
+//
+// Weights are summed exactly, each atomic weight taken as the decimal
+// stored in the table. By default a weight is written to the decimal
+// places of the least precise atomic weight it sums, and a percentage to
+// the significant figures of the less precise of its two weights.
+//
+// The uncertainty of an element's weight is its atoms' count times the
+// uncertainty of its atomic weight, since they all share the same weight;
+// the elements are independent, so the uncertainty of the total is the
+// root of the sum of squares. Labelled atoms add no uncertainty. Interval
+// ranges add up bound by bound, elements without an interval weight
+// adding their atomic weight to both.
 //
 // Returns:
 //   - MolarMassResponse: A response containing the general weight
 //     and detailed information about the elements in the compound.
-func (service MolarMassService) ComputeData(compound models.Compound, elements []models.Element) MolarMassResponse {
+func (service MolarMassService) ComputeData(compound models.Compound, elements []models.Element, isotopes map[models.Nuclide]models.Isotope, precision Precision) MolarMassResponse {
 	var (
-		generalWeight float64                = 0.0
-		elementsInfo  []MolarMassElementInfo = make([]MolarMassElementInfo, len(elements))
+		generalWeight, places = compoundWeight(compound, elements, isotopes)
+		elementsInfo          = make([]MolarMassElementInfo, len(elements))
 	)
 
-	sumWeight := 0.0
+	variance, low, high, interval := 0.0, 0.0, 0.0, false
 	for _, element := range elements {
-		elementWeight := element.AtomicWeight * float64(compound.Data[element.Symbol])
-		sumWeight += elementWeight
+		uncertainty, elementLow, elementHigh := weightSpread(element, compound.Data[element.Symbol], compound.Isotopes, isotopes)
+		variance += uncertainty * uncertainty
+		low += elementLow
+		high += elementHigh
+		interval = interval || elementLow != elementHigh
 	}
-	generalWeight = sumWeight
 
 	for i, element := range elements {
 		elementsInfo[i].Name = element.Name
 		elementsInfo[i].Symbol = element.Symbol
 		elementsInfo[i].AtomsCount = fmt.Sprint(compound.Data[element.Symbol])
-		weigth := element.AtomicWeight * float64(compound.Data[element.Symbol])
-		elementsInfo[i].WeightInCompound = fmt.Sprintf("%.3f", weigth)
-		elementsInfo[i].WeightPercent = fmt.Sprintf("%.3f", weigth/generalWeight*100)
+		weigth, weightPlaces := atomsWeight(element, compound.Data[element.Symbol], compound.Isotopes, isotopes)
+		elementsInfo[i].WeightInCompound = units.Join(precision.FormatExact(weigth, weightPlaces), units.GramPerMole)
+		elementsInfo[i].WeightPercent = formatPercent(precision, weigth, weightPlaces, generalWeight, places)
+		elementsInfo[i].Isotopes = describeLabels(element.Symbol, compound.Isotopes, isotopes)
+		uncertainty, elementLow, elementHigh := weightSpread(element, compound.Data[element.Symbol], compound.Isotopes, isotopes)
+		elementsInfo[i].Uncertainty = formatUncertainty(uncertainty)
+		if elementLow != elementHigh {
+			elementsInfo[i].Interval = formatInterval(elementLow, elementHigh)
+		}
+	}
+
+	response := MolarMassResponse{
+		Total:       units.Join(precision.FormatExact(generalWeight, places), units.GramPerMole),
+		Uncertainty: formatUncertainty(math.Sqrt(variance)),
+		Elements:    elementsInfo,
+		Hydrate:     computeHydrate(compound, elements, isotopes, generalWeight, places, precision),
+	}
+	if interval {
+		response.Interval = formatInterval(low, high)
+	}
+	return response
+}
+
+// molarMass returns the exact molar mass of compound and its decimal
+// places, as GetResponse computes its Total.
+func (service MolarMassService) molarMass(compound models.Compound) (*big.Rat, int, error) {
+	elements, err := service.GetElements(compound)
+	if err != nil {
+		return nil, 0, err
+	}
+	isotopes, err := service.GetIsotopes(compound)
+	if err != nil {
+		return nil, 0, err
+	}
+	weight, places := compoundWeight(compound, elements, isotopes)
+	return weight, places, nil
+}
+
+// compoundWeight returns the exact molar mass of compound and the decimal
+// places it is known to.
+func compoundWeight(compound models.Compound, elements []models.Element, isotopes map[models.Nuclide]models.Isotope) (*big.Rat, int) {
+	total := new(big.Rat)
+	places := 0
+	for i, element := range elements {
+		weight, weightPlaces := atomsWeight(element, compound.Data[element.Symbol], compound.Isotopes, isotopes)
+		total.Add(total, weight)
+		if i == 0 || weightPlaces < places {
+			places = weightPlaces
+		}
+	}
+	return total, places
+}
+
+// formatPercent writes part as a percentage of whole, both exact sums known
+// to the given decimal places, to the significant figures of the less
+// precise of the two.
+func formatPercent(precision Precision, part *big.Rat, partPlaces int, whole *big.Rat, wholePlaces int) string {
+	if whole.Sign() == 0 {
+		return precision.FormatExact(new(big.Rat), 0)
+	}
+	partValue, _ := part.Float64()
+	wholeValue, _ := whole.Float64()
+	figures := min(significantPlaces(partValue, partPlaces), significantPlaces(wholeValue, wholePlaces))
+	percent := new(big.Rat).Quo(part, whole)
+	percent.Mul(percent, big.NewRat(100, 1))
+	percentValue, _ := percent.Float64()
+	return precision.FormatExact(percent, decimalPlaces(percentValue, figures))
+}
+
+// weightSpread returns the uncertainty of the weight of count atoms of
+// element and the range of that weight over the element's IUPAC interval,
+// which collapses to the weight itself when there is none. Labelled atoms
+// weigh the exact mass of their nuclide whatever the bound.
+func weightSpread(element models.Element, count int, labelled map[models.Nuclide]int, isotopes map[models.Nuclide]models.Isotope) (float64, float64, float64) {
+	unlabelled := count
+	for nuclide, labelledCount := range labelled {
+		if nuclide.Symbol == element.Symbol {
+			unlabelled -= labelledCount
+		}
+	}
+	uncertainty := element.Uncertainty * float64(unlabelled)
+	bound := func(element models.Element) float64 {
+		weight, _ := atomsWeight(element, count, labelled, isotopes)
+		value, _ := weight.Float64()
+		return value
+	}
+	if element.IntervalMax == 0 {
+		return uncertainty, bound(element), bound(element)
+	}
+	low, high := element, element
+	low.AtomicWeight, high.AtomicWeight = element.IntervalMin, element.IntervalMax
+	return uncertainty, bound(low), bound(high)
+}
+
+// formatUncertainty writes an uncertainty to two significant figures, as
+// uncertainties are quoted, or returns "" for zero.
+func formatUncertainty(uncertainty float64) string {
+	if uncertainty <= 0 {
+		return ""
+	}
+	decimals := max(1-int(math.Floor(math.Log10(uncertainty))), 0)
+	return units.Join(fmt.Sprintf("%.*f", decimals, uncertainty), units.GramPerMole)
+}
+
+// formatInterval writes the range of a weight over the IUPAC interval
+// weights to five decimal places.
+func formatInterval(low, high float64) string {
+	return units.Join(fmt.Sprintf("[%.5f, %.5f]", low, high), units.GramPerMole)
+}
+
+// atomsWeight returns the exact weight of count atoms of element, the
+// labelled ones among them weighing the exact mass of their nuclide, and
+// the decimal places of the least precise weight it sums.
+func atomsWeight(element models.Element, count int, labelled map[models.Nuclide]int, isotopes map[models.Nuclide]models.Isotope) (*big.Rat, int) {
+	weight := new(big.Rat)
+	places := math.MaxInt
+	add := func(value float64, count int) {
+		exact, valuePlaces := exactDecimal(value)
+		weight.Add(weight, exact.Mul(exact, big.NewRat(int64(count), 1)))
+		places = min(places, valuePlaces)
+	}
+	for nuclide, labelledCount := range labelled {
+		if nuclide.Symbol == element.Symbol {
+			add(isotopes[nuclide].ExactMass, labelledCount)
+			count -= labelledCount
+		}
+	}
+	if count > 0 || places == math.MaxInt {
+		add(element.AtomicWeight, count)
+	}
+	return weight, places
+}
+
+// describeLabels lists the labelled atoms of one element, heaviest nuclide
+// last, as "2H: 2 × 2.014102".
+func describeLabels(symbol string, labelled map[models.Nuclide]int, isotopes map[models.Nuclide]models.Isotope) string {
+	var nuclides []models.Nuclide
+	for nuclide := range labelled {
+		if nuclide.Symbol == symbol {
+			nuclides = append(nuclides, nuclide)
+		}
+	}
+	sort.Slice(nuclides, func(i, j int) bool { return nuclides[i].MassNumber < nuclides[j].MassNumber })
+
+	labels := make([]string, len(nuclides))
+	for i, nuclide := range nuclides {
+		labels[i] = fmt.Sprintf("%s: %d × %.6f", nuclide, labelled[nuclide], isotopes[nuclide].ExactMass)
+	}
+	return strings.Join(labels, ", ")
+}
+
+// computeHydrate splits the weight of an adduct into water of
+// crystallization and the remaining anhydrous part, rounded as the total
+// weight of generalPlaces decimal places is. It returns nil for formulas
+// without dot-joined parts.
+func computeHydrate(compound models.Compound, elements []models.Element, isotopes map[models.Nuclide]models.Isotope, generalWeight *big.Rat, generalPlaces int, precision Precision) *MolarMassHydrateInfo {
+	if len(compound.Adducts) == 0 {
+		return nil
+	}
+
+	bySymbol := make(map[string]models.Element, len(elements))
+	for _, element := range elements {
+		bySymbol[element.Symbol] = element
+	}
+
+	var (
+		anhydrous       []string
+		anhydrousWeight = new(big.Rat)
+		anhydrousPlaces = math.MaxInt
+		waterMolecules  int
+		waterWeight     = new(big.Rat)
+		waterPlaces     = math.MaxInt
+	)
+	for _, adduct := range compound.Adducts {
+		weight := new(big.Rat)
+		places := math.MaxInt
+		for symbol, count := range adduct.Data {
+			atoms, atomsPlaces := atomsWeight(bySymbol[symbol], count, adduct.Isotopes, isotopes)
+			weight.Add(weight, atoms.Mul(atoms, big.NewRat(int64(adduct.Multiplier), 1)))
+			places = min(places, atomsPlaces)
+		}
+		if len(adduct.Data) == 2 && adduct.Data["H"] == 2 && adduct.Data["O"] == 1 {
+			waterMolecules += adduct.Multiplier
+			waterWeight.Add(waterWeight, weight)
+			waterPlaces = min(waterPlaces, places)
+			continue
+		}
+		if adduct.Multiplier == 1 {
+			anhydrous = append(anhydrous, adduct.Formula)
+		} else {
+			anhydrous = append(anhydrous, fmt.Sprintf("%d%s", adduct.Multiplier, adduct.Formula))
+		}
+		anhydrousWeight.Add(anhydrousWeight, weight)
+		anhydrousPlaces = min(anhydrousPlaces, places)
 	}
+	anhydrousPlaces = min(anhydrousPlaces, generalPlaces)
+	waterPlaces = min(waterPlaces, generalPlaces)
 
-	return MolarMassResponse{
-		Total:    generalWeight,
-		Elements: elementsInfo,
+	return &MolarMassHydrateInfo{
+		Anhydrous:       strings.Join(anhydrous, "·"),
+		AnhydrousWeight: units.Join(precision.FormatExact(anhydrousWeight, anhydrousPlaces), units.GramPerMole),
+		WaterMolecules:  fmt.Sprint(waterMolecules),
+		WaterWeight:     units.Join(precision.FormatExact(waterWeight, waterPlaces), units.GramPerMole),
+		WaterPercent:    formatPercent(precision, waterWeight, waterPlaces, generalWeight, generalPlaces),
 	}
 }
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"ChemistryPR/internal/models"
+	"math/big"
+	"sort"
+)
+
+// electronegativity holds Pauling electronegativities used to decide which
+// element of a compound receives its typical negative oxidation state.
+// Elements missing from the table are treated as metals with 1.5.
+var electronegativity = map[string]float64{
+	"H": 2.20, "Li": 0.98, "Be": 1.57, "B": 2.04, "C": 2.55, "N": 3.04, "O": 3.44, "F": 3.98,
+	"Na": 0.93, "Mg": 1.31, "Al": 1.61, "Si": 1.90, "P": 2.19, "S": 2.58, "Cl": 3.16,
+	"K": 0.82, "Ca": 1.00, "Sc": 1.36, "Ti": 1.54, "V": 1.63, "Cr": 1.66, "Mn": 1.55,
+	"Fe": 1.83, "Co": 1.88, "Ni": 1.91, "Cu": 1.90, "Zn": 1.65, "Ga": 1.81, "Ge": 2.01,
+	"As": 2.18, "Se": 2.55, "Br": 2.96, "Kr": 3.00, "Rb": 0.82, "Sr": 0.95, "Y": 1.22,
+	"Zr": 1.33, "Nb": 1.60, "Mo": 2.16, "Tc": 1.90, "Ru": 2.20, "Rh": 2.28, "Pd": 2.20,
+	"Ag": 1.93, "Cd": 1.69, "In": 1.78, "Sn": 1.96, "Sb": 2.05, "Te": 2.10, "I": 2.66,
+	"Xe": 2.60, "Cs": 0.79, "Ba": 0.89, "La": 1.10, "Ce": 1.12, "Hf": 1.30, "Ta": 1.50,
+	"W": 2.36, "Re": 1.90, "Os": 2.20, "Ir": 2.20, "Pt": 2.28, "Au": 2.54, "Hg": 2.00,
+	"Tl": 1.62, "Pb": 2.33, "Bi": 2.02, "Po": 2.00, "At": 2.20, "Th": 1.30, "U": 1.38,
+}
+
+// fixedOxidationStates lists elements whose oxidation state in compounds is
+// fixed by the usual textbook rules, in the order the rules are applied.
+var fixedOxidationStates = []struct {
+	symbols []string
+	state   int64
+}{
+	{[]string{"F"}, -1},
+	{[]string{"Li", "Na", "K", "Rb", "Cs", "Fr"}, 1},
+	{[]string{"Be", "Mg", "Ca", "Sr", "Ba", "Ra"}, 2},
+	{[]string{"Al"}, 3},
+	{[]string{"H"}, 1},
+	{[]string{"O"}, -2},
+}
+
+// negativeOxidationStates holds the typical state of a nonmetal when it is
+// the most electronegative element left to assign.
+var negativeOxidationStates = map[string]int64{
+	"Cl": -1, "Br": -1, "I": -1, "N": -3, "P": -3, "As": -3,
+	"S": -2, "Se": -2, "Te": -2, "C": -4, "Si": -4, "B": -3,
+}
+
+// hydrogenThreshold is the electronegativity above which a partner makes
+// hydrogen +1 rather than a -1 hydride.
+const hydrogenThreshold = 2.1
+
+// electronegativityOf returns the tabulated value for symbol or 1.5.
+func electronegativityOf(symbol string) float64 {
+	if value, ok := electronegativity[symbol]; ok {
+		return value
+	}
+	return 1.5
+}
+
+// oxidationStates assigns an oxidation state to every element of compound.
+//
+// Elements are ordered by the textbook rules (F, alkali metals, alkaline
+// earth metals, Al, H, O) followed by the remaining elements from the most
+// to the least electronegative. Every element but the last receives its
+// fixed or typical state and the last one takes whatever keeps the sum
+// equal to the charge, so peroxides, superoxides and OF2 come out right and
+// mixed-valence compounds such as Fe3O4 get an average state.
+func oxidationStates(compound models.Compound) (map[string]*big.Rat, error) {
+	states := make(map[string]*big.Rat, len(compound.Data))
+	if len(compound.Data) == 0 {
+		return states, nil
+	}
+
+	rank := make(map[string]int)
+	for i, rule := range fixedOxidationStates {
+		for _, symbol := range rule.symbols {
+			rank[symbol] = i
+		}
+	}
+	symbols := make([]string, 0, len(compound.Data))
+	for symbol := range compound.Data {
+		symbols = append(symbols, symbol)
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		ri, oki := rank[symbols[i]]
+		rj, okj := rank[symbols[j]]
+		switch {
+		case oki && okj:
+			return ri < rj
+		case oki != okj:
+			return oki
+		default:
+			return electronegativityOf(symbols[i]) > electronegativityOf(symbols[j])
+		}
+	})
+
+	remainder := big.NewRat(int64(compound.Charge), 1)
+	for _, symbol := range symbols[:len(symbols)-1] {
+		state, err := typicalOxidationState(symbol, compound)
+		if err != nil {
+			return nil, err
+		}
+		states[symbol] = big.NewRat(state, 1)
+		remainder.Sub(remainder, big.NewRat(state*int64(compound.Data[symbol]), 1))
+	}
+
+	last := symbols[len(symbols)-1]
+	states[last] = remainder.Quo(remainder, big.NewRat(int64(compound.Data[last]), 1))
+	return states, nil
+}
+
+// typicalOxidationState returns the state symbol takes in compound when it
+// is not the element that absorbs the remainder.
+func typicalOxidationState(symbol string, compound models.Compound) (int64, error) {
+	if symbol == "H" {
+		for partner := range compound.Data {
+			if partner != "H" && electronegativityOf(partner) > hydrogenThreshold {
+				return 1, nil
+			}
+		}
+		return -1, nil
+	}
+	for _, rule := range fixedOxidationStates {
+		for _, fixed := range rule.symbols {
+			if fixed == symbol {
+				return rule.state, nil
+			}
+		}
+	}
+	if state, ok := negativeOxidationStates[symbol]; ok {
+		return state, nil
+	}
+	return 0, inputErrorf("cannot determine the oxidation state of %s in %s", symbol, compound.Formula)
+}
+
+// formatOxidationState renders a state as "+7", "-2", "0" or "+8/3".
+func formatOxidationState(state *big.Rat) string {
+	text := state.RatString()
+	if state.Sign() > 0 {
+		return "+" + text
+	}
+	return text
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Rounding selects how a calculator rounds the numbers it writes.
+type Rounding string
+
+const (
+	DataRounding    Rounding = "data"     // Significant figures follow the precision of the input data
+	DecimalRounding Rounding = "decimals" // A fixed number of decimal places
+	FigureRounding  Rounding = "sigfigs"  // A fixed number of significant figures
+)
+
+// maxDigits bounds the digits a Precision may ask for, beyond which
+// float64 results carry no information.
+const maxDigits = 15
+
+// Precision holds the rounding option shared by all calculators. The zero
+// value follows the data: sums keep the decimal places of their least
+// precise term, products and quotients the significant figures of their
+// least precise factor, and counts of atoms are exact.
+type Precision struct {
+	Rounding Rounding // DataRounding when empty
+	Digits   int      // Decimal places for DecimalRounding, significant figures for FigureRounding
+}
+
+// ErrPrecision is returned for a Precision that asks for an unknown
+// rounding or an impossible number of digits.
+var ErrPrecision = errors.New("invalid precision")
+
+// Validate checks that precision can be used to write numbers.
+func (precision Precision) Validate() error {
+	switch precision.Rounding {
+	case "", DataRounding:
+		return nil
+	case DecimalRounding:
+		if precision.Digits < 0 || precision.Digits > maxDigits {
+			return fmt.Errorf("%w: decimal places must be between 0 and %d, got %d", ErrPrecision, maxDigits, precision.Digits)
+		}
+	case FigureRounding:
+		if precision.Digits < 1 || precision.Digits > maxDigits {
+			return fmt.Errorf("%w: significant figures must be between 1 and %d, got %d", ErrPrecision, maxDigits, precision.Digits)
+		}
+	default:
+		return fmt.Errorf("%w: unknown rounding %q", ErrPrecision, precision.Rounding)
+	}
+	return nil
+}
+
+// exactDecimal returns the shortest decimal that reads back as value,
+// exactly, and its decimal places: a weight of 1.008 read from the database
+// is 1008/1000 known to three places rather than the nearest binary
+// fraction.
+func exactDecimal(value float64) (*big.Rat, int) {
+	text := strconv.FormatFloat(value, 'f', -1, 64)
+	exact, _ := new(big.Rat).SetString(text)
+	places := 0
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		places = len(text) - i - 1
+	}
+	return exact, places
+}
+
+// decimalPlaces returns the decimal places of a value known to figures
+// significant figures, negative when even its units digit is uncertain.
+func decimalPlaces(value float64, figures int) int {
+	if value == 0 {
+		return figures - 1
+	}
+	return figures - 1 - int(math.Floor(math.Log10(math.Abs(value))))
+}
+
+// significantPlaces returns the significant figures of a value known to
+// the given decimal places, as a sum is; at least one.
+func significantPlaces(value float64, places int) int {
+	if value == 0 {
+		return max(places+1, 1)
+	}
+	return max(places+1+int(math.Floor(math.Log10(math.Abs(value)))), 1)
+}
+
+// roundedPlaces returns the decimal places of value written to figures
+// significant figures. Rounding that carries value to the next power of
+// ten, as 0.99996 to 1.000 at four figures, leaves one place fewer.
+func roundedPlaces(value float64, figures int) int {
+	places := decimalPlaces(value, figures)
+	scale := math.Pow(10, float64(places))
+	if rounded := math.Round(value*scale) / scale; rounded != 0 {
+		places = min(places, decimalPlaces(rounded, figures))
+	}
+	return places
+}
+
+// Format writes value, the result of products and quotients known to the
+// given significant figures, as precision asks.
+func (precision Precision) Format(value float64, figures int) string {
+	exact, _ := exactDecimal(value)
+	return precision.FormatExact(exact, roundedPlaces(value, figures))
+}
+
+// FormatExact writes an exact value, the result of sums known to the given
+// decimal places, as precision asks, rounding halves away from zero.
+func (precision Precision) FormatExact(value *big.Rat, places int) string {
+	switch precision.Rounding {
+	case DecimalRounding:
+		places = precision.Digits
+	case FigureRounding:
+		approximate, _ := value.Float64()
+		places = roundedPlaces(approximate, precision.Digits)
+	}
+	if places >= 0 {
+		return value.FloatString(places)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-places)), nil)
+	rounded, _ := new(big.Int).SetString(new(big.Rat).Quo(value, new(big.Rat).SetInt(scale)).FloatString(0), 10)
+	return rounded.Mul(rounded, scale).String()
+}
+
+// FormatScientific writes value, known to the given significant figures,
+// in scientific notation as "6.022e23", for counts of particles too large
+// to write out. The digits of precision apply to the mantissa.
+func (precision Precision) FormatScientific(value float64, figures int) string {
+	if value == 0 {
+		return precision.Format(0, figures)
+	}
+	exponent := int(math.Floor(math.Log10(math.Abs(value))))
+	mantissa := value / math.Pow(10, float64(exponent))
+	places := figures - 1
+	switch precision.Rounding {
+	case DecimalRounding:
+		places = precision.Digits
+	case FigureRounding:
+		places = precision.Digits - 1
+	}
+	exact, _ := exactDecimal(mantissa)
+	text := exact.FloatString(places)
+	// Rounding may carry the mantissa to 10, as 9.9996e22 to "10.00".
+	if strings.HasPrefix(strings.TrimLeft(text, "-"), "10") {
+		exponent++
+		exact, _ = exactDecimal(mantissa / 10)
+		text = exact.FloatString(places)
+	}
+	return fmt.Sprintf("%se%d", text, exponent)
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestFormatExact(t *testing.T) {
+	tests := []struct {
+		value     string
+		places    int
+		precision Precision
+		want      string
+	}{
+		{value: "18.015", places: 3, want: "18.015"},
+		{value: "2.016", places: 3, want: "2.016"},
+		{value: "1.0075", places: 3, want: "1.008"},
+		{value: "-1.0075", places: 3, want: "-1.008"},
+		{value: "1234.5", places: -2, want: "1200"},
+		{value: "1250", places: -2, want: "1300"},
+		{value: "58.4428", places: 4, precision: Precision{Rounding: DecimalRounding, Digits: 2}, want: "58.44"},
+		{value: "58.4428", places: 4, precision: Precision{Rounding: DecimalRounding, Digits: 0}, want: "58"},
+		{value: "58.4428", places: 4, precision: Precision{Rounding: FigureRounding, Digits: 3}, want: "58.4"},
+		{value: "0.0012345", places: 7, precision: Precision{Rounding: FigureRounding, Digits: 2}, want: "0.0012"},
+		{value: "98765", places: 0, precision: Precision{Rounding: FigureRounding, Digits: 2}, want: "99000"},
+	}
+
+	for _, test := range tests {
+		value, _ := new(big.Rat).SetString(test.value)
+		if got := test.precision.FormatExact(value, test.places); got != test.want {
+			t.Errorf("%+v.FormatExact(%s, %d) = %s, want %s", test.precision, test.value, test.places, got, test.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		value     float64
+		figures   int
+		precision Precision
+		want      string
+	}{
+		{value: 0.1, figures: 3, want: "0.100"},
+		{value: 1.4611, figures: 3, want: "1.46"},
+		{value: 2.5, figures: 1, want: "3"},
+		{value: 22.414, figures: 3, want: "22.4"},
+		{value: 12345, figures: 2, want: "12000"},
+		{value: 0, figures: 2, want: "0.0"},
+		{value: 0.99998, figures: 4, want: "1.000"},
+		{value: 99996, figures: 4, want: "100000"},
+		{value: -9.96, figures: 2, want: "-10"},
+		{value: 0.99998, figures: 3, precision: Precision{Rounding: FigureRounding, Digits: 4}, want: "1.000"},
+		{value: 1.4611, figures: 3, precision: Precision{Rounding: DecimalRounding, Digits: 1}, want: "1.5"},
+		{value: 1.4611, figures: 3, precision: Precision{Rounding: FigureRounding, Digits: 5}, want: "1.4611"},
+	}
+
+	for _, test := range tests {
+		if got := test.precision.Format(test.value, test.figures); got != test.want {
+			t.Errorf("%+v.Format(%v, %d) = %s, want %s", test.precision, test.value, test.figures, got, test.want)
+		}
+	}
+}
+
+func TestFormatScientific(t *testing.T) {
+	tests := []struct {
+		value     float64
+		figures   int
+		precision Precision
+		want      string
+	}{
+		{value: 6.02214076e23, figures: 4, want: "6.022e23"},
+		{value: 1.5e-5, figures: 2, want: "1.5e-5"},
+		{value: 6.02214076e23, figures: 4, precision: Precision{Rounding: FigureRounding, Digits: 2}, want: "6.0e23"},
+	}
+
+	for _, test := range tests {
+		if got := test.precision.FormatScientific(test.value, test.figures); got != test.want {
+			t.Errorf("%+v.FormatScientific(%v, %d) = %s, want %s", test.precision, test.value, test.figures, got, test.want)
+		}
+	}
+}
+
+func TestPrecisionValidate(t *testing.T) {
+	tests := []struct {
+		precision Precision
+		valid     bool
+	}{
+		{precision: Precision{}, valid: true},
+		{precision: Precision{Rounding: DataRounding}, valid: true},
+		{precision: Precision{Rounding: DecimalRounding, Digits: 0}, valid: true},
+		{precision: Precision{Rounding: DecimalRounding, Digits: maxDigits + 1}},
+		{precision: Precision{Rounding: FigureRounding, Digits: 0}},
+		{precision: Precision{Rounding: FigureRounding, Digits: maxDigits}, valid: true},
+		{precision: Precision{Rounding: "nearest"}},
+	}
+
+	for _, test := range tests {
+		err := test.precision.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%+v.Validate(): %v", test.precision, err)
+		}
+		if !test.valid && !errors.Is(err, ErrPrecision) {
+			t.Errorf("%+v.Validate() error = %v, want %v", test.precision, err, ErrPrecision)
+		}
+	}
+}
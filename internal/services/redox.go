@@ -0,0 +1,659 @@
+package services
+
+import (
+	"ChemistryPR/internal/models"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrNotRedox is reported by the redox mode when no element both loses and
+// gains electrons in the equation.
+var ErrNotRedox = errors.New("not a redox reaction")
+
+// Medium is the solution in which half-reactions are balanced.
+type Medium string
+
+const (
+	AcidicMedium Medium = "acidic" // O and H are balanced with H2O and H^+
+	BasicMedium  Medium = "basic"  // O and H are balanced with H2O and OH^-
+)
+
+// OxidationChange records an element whose oxidation state differs between
+// a reagent and a product.
+type OxidationChange struct {
+	Element string // Symbol of the element, e.g. "Mn"
+	Reagent string // Reagent the element starts in, e.g. "MnO4^-"
+	Product string // Product the element ends in, e.g. "Mn^2+"
+	From    string // Oxidation state in the reagent, e.g. "+7"
+	To      string // Oxidation state in the product, e.g. "+2"
+}
+
+// HalfReaction is one balanced half of a redox reaction.
+type HalfReaction struct {
+	Equation   string // Balanced half-reaction including electrons, e.g. "Fe^2+ = Fe^3+ + e-"
+	Electrons  int    // Electrons lost (oxidation) or gained (reduction) by the half-reaction
+	Multiplier int    // Factor the half-reaction is multiplied by so both halves exchange the same electrons
+}
+
+// RedoxResponse explains how an equation was balanced by the half-reaction
+// method.
+type RedoxResponse struct {
+	Medium    Medium
+	Changes   []OxidationChange // Elements that change oxidation state
+	Oxidation HalfReaction
+	Reduction HalfReaction
+	Electrons int    // Electrons transferred in the overall balanced equation
+	NetIonic  string // Balanced net ionic equation the halves come from, set when the equation was molecular
+}
+
+// redoxSpecies is a species of a redox equation together with the
+// oxidation states of its elements.
+type redoxSpecies struct {
+	formula  string
+	compound models.Compound
+	states   map[string]*big.Rat
+	key      string
+}
+
+// redoxIon is one of the ions a species is split into, with the number of
+// them in a formula unit.
+type redoxIon struct {
+	species redoxSpecies
+	count   int
+}
+
+// redoxAnions are the anions a neutral salt or acid is split into when its
+// metal is not one of spectatorCations, by their element counts.
+var redoxAnions = []struct {
+	counts map[string]int
+	charge int
+}{
+	{map[string]int{"Cl": 1}, -1}, {map[string]int{"Br": 1}, -1}, {map[string]int{"I": 1}, -1},
+	{map[string]int{"N": 1, "O": 3}, -1}, {map[string]int{"Cl": 1, "O": 4}, -1}, {map[string]int{"S": 1, "O": 4}, -2},
+}
+
+// redoxTerm is a species with a signed coefficient: positive on the reagent
+// side and negative on the product side.
+type redoxTerm struct {
+	species     redoxSpecies
+	coefficient int64
+}
+
+// RedoxBalancer balances equations by the half-reaction method. It detects
+// the elements that change oxidation state, splits the equation into an
+// oxidation and a reduction half, balances each one in the chosen medium and
+// combines them so that the electrons cancel.
+//
+// The equation may be written in net ionic form, where every species must
+// take part in a half-reaction or be one of H2O, H^+ and OH^-, or as a
+// molecular equation such as "KMnO4 + HCl = KCl + MnCl2 + Cl2 + H2O". Salts,
+// strong acids and hydroxides of a molecular equation are split into their
+// ions, the halves are taken from the resulting net ionic equation, and
+// the spectator ions, such as K^+ or the Cl^- that is not oxidised, are
+// added back to balance the equation as written.
+type RedoxBalancer struct {
+	ChemicalService
+	Medium Medium // Medium of the half-reactions; when empty it is basic if OH^- is present and acidic otherwise
+}
+
+// Balance returns the balanced equation, which may gain H2O, H^+ or OH^-
+// and lose species that cancel out, its coefficients and the half-reaction
+// working.
+func (balancer RedoxBalancer) Balance(equation Equation) (Equation, []int, RedoxResponse, error) {
+	var response RedoxResponse
+	failure := func(reason error, format string, args ...any) error {
+		return &BalanceError{Reaction: equation.Reaction, Reason: reason, Detail: fmt.Sprintf(format, args...)}
+	}
+
+	written, err := balancer.redoxSpecies(equation.Species())
+	if err != nil {
+		return Equation{}, nil, response, err
+	}
+	parts := make([][]redoxIon, len(written))
+	molecular := false
+	for i, species := range written {
+		if species.compound.Formula == "e-" {
+			return Equation{}, nil, response, failure(ErrMalformedEquation, "electrons are added by the redox mode and must not be given")
+		}
+		if parts[i], err = balancer.dissociate(species); err != nil {
+			return Equation{}, nil, response, err
+		}
+		molecular = molecular || len(parts[i]) > 1
+	}
+	reagents := distinctIons(parts[:len(equation.Reagents)])
+	products := distinctIons(parts[len(equation.Reagents):])
+
+	medium, err := balancer.redoxSpecies([]string{"H2O", "H^+", "OH^-", "e-"})
+	if err != nil {
+		return Equation{}, nil, response, err
+	}
+	water, hydrogen, hydroxide, electron := medium[0], medium[1], medium[2], medium[3]
+	response.Medium = balancer.Medium
+	if response.Medium == "" {
+		response.Medium = AcidicMedium
+		for _, species := range append(append([]redoxSpecies{}, reagents...), products...) {
+			if species.key == hydroxide.key {
+				response.Medium = BasicMedium
+			}
+		}
+	}
+	helpers := []redoxSpecies{water, hydrogen, electron}
+	if response.Medium == BasicMedium {
+		helpers = []redoxSpecies{water, hydroxide, electron}
+	} else if response.Medium != AcidicMedium {
+		return Equation{}, nil, response, failure(ErrMalformedEquation, "unknown medium %q", response.Medium)
+	}
+
+	oxidation, reduction, changes := splitHalves(reagents, products)
+	response.Changes = changes
+	if len(oxidation) == 0 || len(reduction) == 0 {
+		return Equation{}, nil, response, failure(ErrNotRedox, "no element is both oxidised and reduced")
+	}
+
+	participants := map[string]bool{water.key: true, hydrogen.key: true, hydroxide.key: true}
+	for _, term := range append(append([]redoxTerm{}, oxidation...), reduction...) {
+		participants[term.species.key] = true
+	}
+	if molecular {
+		// Spectator ions may take no part as long as they are found on
+		// both sides.
+		for _, reagent := range reagents {
+			for _, product := range products {
+				if reagent.key == product.key {
+					participants[reagent.key] = true
+				}
+			}
+		}
+	}
+	for _, species := range append(append([]redoxSpecies{}, reagents...), products...) {
+		if !participants[species.key] {
+			return Equation{}, nil, response, failure(ErrUnbalanceable,
+				"%s does not take part in electron transfer; write the net ionic equation", species.formula)
+		}
+	}
+
+	oxidationTerms, lost, err := balanceHalf(equation.Reaction, oxidation, helpers, electron.key, -1)
+	if err != nil {
+		return Equation{}, nil, response, err
+	}
+	reductionTerms, gained, err := balanceHalf(equation.Reaction, reduction, helpers, electron.key, 1)
+	if err != nil {
+		return Equation{}, nil, response, err
+	}
+
+	transferred := lcm(lost, gained)
+	response.Oxidation = HalfReaction{Equation: formatTerms(oxidationTerms), Electrons: int(lost), Multiplier: int(transferred / lost)}
+	response.Reduction = HalfReaction{Equation: formatTerms(reductionTerms), Electrons: int(gained), Multiplier: int(transferred / gained)}
+
+	order := make([]redoxSpecies, 0)
+	order = append(order, reagents...)
+	order = append(order, products...)
+	order = append(order, helpers...)
+	combined := make(map[string]int64)
+	for _, term := range oxidationTerms {
+		combined[term.species.key] += term.coefficient * int64(response.Oxidation.Multiplier)
+	}
+	for _, term := range reductionTerms {
+		combined[term.species.key] += term.coefficient * int64(response.Reduction.Multiplier)
+	}
+
+	divisor := int64(0)
+	for _, coefficient := range combined {
+		divisor = gcd(divisor, abs(coefficient))
+	}
+	response.Electrons = int(transferred / divisor)
+
+	result := Equation{}
+	var reagentCoefficients, productCoefficients []int
+	seen := make(map[string]bool)
+	for _, species := range order {
+		coefficient := combined[species.key] / divisor
+		if seen[species.key] || coefficient == 0 {
+			continue
+		}
+		seen[species.key] = true
+		if coefficient > 0 {
+			result.Reagents = append(result.Reagents, species.formula)
+			reagentCoefficients = append(reagentCoefficients, int(coefficient))
+		} else {
+			result.Products = append(result.Products, species.formula)
+			productCoefficients = append(productCoefficients, int(-coefficient))
+		}
+	}
+	result.Reaction = result.Format(append(reagentCoefficients, productCoefficients...))
+	if !molecular {
+		return result, append(reagentCoefficients, productCoefficients...), response, nil
+	}
+
+	response.NetIonic = result.Reaction
+	net := make(map[string]int64, len(combined))
+	formulas := make(map[string]string, len(order))
+	for _, species := range order {
+		net[species.key] = combined[species.key] / divisor
+		formulas[species.key] = species.formula
+	}
+	coefficients, scale, err := restoreSpectators(equation, parts, net, formulas)
+	if err != nil {
+		return Equation{}, nil, response, err
+	}
+	response.Electrons *= int(scale)
+	balanced := Equation{Reagents: equation.Reagents, Products: equation.Products}
+	balanced.Reaction = balanced.Format(coefficients)
+	return balanced, coefficients, response, nil
+}
+
+// dissociate splits a neutral salt, strong acid or hydroxide into its
+// ions. An alkali or alkaline-earth metal of spectatorCations leaves a
+// single anion behind, as in KMnO4, K2Cr2O7 and NaOH; otherwise the anions
+// of redoxAnions are split off when what remains is hydrogen, ammonium or
+// a single element, as in HNO3, NH4Cl and Fe2(SO4)3. Any other species,
+// ions and elements among them, is returned whole.
+func (balancer RedoxBalancer) dissociate(species redoxSpecies) ([]redoxIon, error) {
+	whole := []redoxIon{{species: species, count: 1}}
+	data := species.compound.Data
+	if species.compound.Charge != 0 || len(data) < 2 {
+		return whole, nil
+	}
+	order := symbolOrder(species.formula)
+
+	// split forms the count ions of each of the two parts, which hold
+	// their counts and total charges.
+	split := func(first map[string]int, firstCount, firstCharge int, second map[string]int, secondCount, secondCharge int) ([]redoxIon, error) {
+		formulas := []string{ionFormula(order, first, firstCharge/firstCount), ionFormula(order, second, secondCharge/secondCount)}
+		ions, err := balancer.redoxSpecies(formulas)
+		if err != nil {
+			return nil, err
+		}
+		return []redoxIon{{species: ions[0], count: firstCount}, {species: ions[1], count: secondCount}}, nil
+	}
+
+	for _, cation := range spectatorCations {
+		count := data[cation.symbol]
+		if count == 0 {
+			continue
+		}
+		rest := removeCounts(data, map[string]int{cation.symbol: 1}, count)
+		return split(map[string]int{cation.symbol: 1}, count, count*cation.charge, rest, 1, -count*cation.charge)
+	}
+	for _, anion := range redoxAnions {
+		removed := 0
+		for removeCounts(data, anion.counts, removed+1) != nil {
+			removed++
+		}
+		if removed == 0 {
+			continue
+		}
+		rest := removeCounts(data, anion.counts, removed)
+		charge := -removed * anion.charge
+		units := 0
+		switch {
+		case len(rest) == 1 && rest["H"] == charge:
+			units = charge
+		case len(rest) == 1:
+			for _, count := range rest {
+				units = int(gcd(int64(count), int64(charge)))
+			}
+		case len(rest) == 2 && rest["H"] == 4*rest["N"]:
+			units = rest["N"]
+		}
+		if units == 0 || charge%units != 0 {
+			continue
+		}
+		cation, _ := divideCounts(rest, units)
+		return split(cation, units, charge, anion.counts, removed, removed*anion.charge)
+	}
+	return whole, nil
+}
+
+// distinctIons lists the ions of the species of one side, each once.
+func distinctIons(parts [][]redoxIon) []redoxSpecies {
+	var ions []redoxSpecies
+	seen := make(map[string]bool)
+	for _, species := range parts {
+		for _, ion := range species {
+			if !seen[ion.species.key] {
+				seen[ion.species.key] = true
+				ions = append(ions, ion.species)
+			}
+		}
+	}
+	return ions
+}
+
+// restoreSpectators finds the coefficients of the molecular equation whose
+// ions, once the spectators found on both sides cancel, are a multiple of
+// the net ionic equation, given by the signed coefficients of its species
+// keyed by speciesKey. It returns the coefficients and the multiple.
+func restoreSpectators(equation Equation, parts [][]redoxIon, net map[string]int64, formulas map[string]string) ([]int, int64, error) {
+	failure := func(format string, args ...any) error {
+		return &BalanceError{Reaction: equation.Reaction, Reason: ErrUnbalanceable, Detail: fmt.Sprintf(format, args...)}
+	}
+
+	rows := make(map[string]int)
+	for _, ions := range parts {
+		for _, ion := range ions {
+			if _, ok := rows[ion.species.key]; !ok {
+				rows[ion.species.key] = len(rows)
+			}
+		}
+	}
+	for key, coefficient := range net {
+		if _, ok := rows[key]; !ok && coefficient != 0 {
+			return nil, 0, failure("the half-reactions need %s; add it or a compound that gives it to the equation", formulas[key])
+		}
+	}
+
+	// Every ion is conserved up to the multiple of the net ionic equation
+	// in the last column.
+	scaleColumn := len(parts)
+	matrix := newRatMatrix(len(rows), scaleColumn+1)
+	for col, ions := range parts {
+		sign := int64(1)
+		if col >= len(equation.Reagents) {
+			sign = -1
+		}
+		for _, ion := range ions {
+			cell := matrix[rows[ion.species.key]][col]
+			cell.Add(cell, big.NewRat(sign*int64(ion.count), 1))
+		}
+	}
+	for key, row := range rows {
+		matrix[row][scaleColumn].SetInt64(-net[key])
+	}
+
+	basis, _ := matrix.nullspace(nil)
+	if len(basis) != 1 {
+		return nil, 0, failure("the spectator ions can be added back in more than one way")
+	}
+	vector := integerVector(basis[0])
+	if vector[scaleColumn].Sign() < 0 {
+		for _, value := range vector {
+			value.Neg(value)
+		}
+	}
+	coefficients := make([]int, scaleColumn)
+	for col := range coefficients {
+		if vector[col].Sign() <= 0 {
+			return nil, 0, failure("the spectator ions cannot be balanced")
+		}
+		coefficients[col] = int(vector[col].Int64())
+	}
+	return coefficients, vector[scaleColumn].Int64(), nil
+}
+
+// symbolOrder lists the element symbols of formula in the order they are
+// first written.
+func symbolOrder(formula string) []string {
+	var symbols []string
+	runes := []rune(formula)
+	for i := 0; i < len(runes); i++ {
+		if !unicode.IsUpper(runes[i]) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && unicode.IsLower(runes[end]) {
+			end++
+		}
+		if symbol := string(runes[i:end]); !slices.Contains(symbols, symbol) {
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols
+}
+
+// ionFormula writes the ion of counts and charge with its elements in the
+// given order, as in "MnO4^-" or "Fe^3+".
+func ionFormula(order []string, counts map[string]int, charge int) string {
+	var formula strings.Builder
+	for _, symbol := range order {
+		switch count := counts[symbol]; {
+		case count == 1:
+			formula.WriteString(symbol)
+		case count > 1:
+			fmt.Fprintf(&formula, "%s%d", symbol, count)
+		}
+	}
+	sign := "+"
+	if charge < 0 {
+		sign = "-"
+	}
+	if magnitude := abs(int64(charge)); magnitude > 1 {
+		fmt.Fprintf(&formula, "^%d%s", magnitude, sign)
+	} else if magnitude == 1 {
+		formula.WriteString("^" + sign)
+	}
+	return formula.String()
+}
+
+// redoxSpecies parses formulas and computes their oxidation states.
+func (balancer RedoxBalancer) redoxSpecies(formulas []string) ([]redoxSpecies, error) {
+	species := make([]redoxSpecies, len(formulas))
+	for i, formula := range formulas {
+		compound, err := balancer.ParseCompound(formula)
+		if err != nil {
+			return nil, err
+		}
+		states, err := oxidationStates(compound)
+		if err != nil {
+			return nil, err
+		}
+		species[i] = redoxSpecies{formula: formula, compound: compound, states: states, key: speciesKey(compound)}
+	}
+	return species, nil
+}
+
+// speciesKey identifies a species by composition and charge so that "H+"
+// and "H^+" are recognised as the same ion.
+func speciesKey(compound models.Compound) string {
+	symbols := make([]string, 0, len(compound.Data))
+	for symbol := range compound.Data {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	var key strings.Builder
+	for _, symbol := range symbols {
+		fmt.Fprintf(&key, "%s%d", symbol, compound.Data[symbol])
+	}
+	fmt.Fprintf(&key, "|%d", compound.Charge)
+	return key.String()
+}
+
+// splitHalves pairs every product element with the reagent it comes from.
+// A product whose element has the same state in some reagent is treated as
+// a plain transfer; otherwise it is paired with the reagent of the closest
+// state and the pair joins the oxidation or the reduction half. The terms
+// carry the sign of their side and a zero magnitude.
+func splitHalves(reagents, products []redoxSpecies) ([]redoxTerm, []redoxTerm, []OxidationChange) {
+	var oxidation, reduction []redoxTerm
+	var changes []OxidationChange
+	add := func(terms []redoxTerm, species redoxSpecies, sign int64) []redoxTerm {
+		for _, term := range terms {
+			if term.species.key == species.key && term.coefficient == sign {
+				return terms
+			}
+		}
+		return append(terms, redoxTerm{species: species, coefficient: sign})
+	}
+
+	for _, product := range products {
+		symbols := make([]string, 0, len(product.states))
+		for symbol := range product.states {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+
+		for _, symbol := range symbols {
+			state := product.states[symbol]
+			var source *redoxSpecies
+			var distance *big.Rat
+			unchanged := false
+			for i, reagent := range reagents {
+				reagentState, ok := reagent.states[symbol]
+				if !ok {
+					continue
+				}
+				if reagentState.Cmp(state) == 0 {
+					unchanged = true
+					break
+				}
+				difference := new(big.Rat).Sub(state, reagentState)
+				difference.Abs(difference)
+				if distance == nil || difference.Cmp(distance) < 0 {
+					source, distance = &reagents[i], difference
+				}
+			}
+			if unchanged || source == nil {
+				continue
+			}
+
+			changes = append(changes, OxidationChange{
+				Element: symbol,
+				Reagent: source.formula,
+				Product: product.formula,
+				From:    formatOxidationState(source.states[symbol]),
+				To:      formatOxidationState(state),
+			})
+			if state.Cmp(source.states[symbol]) > 0 {
+				oxidation = add(add(oxidation, *source, 1), product, -1)
+			} else {
+				reduction = add(add(reduction, *source, 1), product, -1)
+			}
+		}
+	}
+	return oxidation, reduction, changes
+}
+
+// balanceHalf balances the species of one half-reaction with the helper
+// species of the medium. Main species keep their side, helpers may land on
+// either side. electronSign is -1 when the electrons must be released and 1
+// when they must be consumed. It returns the signed terms and the number of
+// electrons.
+//
+// When water leaves the half-reaction underdetermined, as with H2O2 = O2,
+// the half is balanced again without the water helper.
+func balanceHalf(reaction string, mains []redoxTerm, helpers []redoxSpecies, electronKey string, electronSign int64) ([]redoxTerm, int64, error) {
+	names := make([]string, len(mains))
+	for i, term := range mains {
+		names[i] = term.species.formula
+	}
+	failure := func(format string) error {
+		return &BalanceError{Reaction: reaction, Reason: ErrUnbalanceable,
+			Detail: fmt.Sprintf(format, strings.Join(names, ", "))}
+	}
+
+	columns, vector := halfNullspace(mains, helpers)
+	if vector == nil {
+		columns, vector = halfNullspace(mains, helpers[1:])
+	}
+	if vector == nil {
+		return nil, 0, failure("half-reaction of %s cannot be balanced uniquely")
+	}
+
+	terms := make([]redoxTerm, 0, len(columns))
+	electrons := int64(0)
+	for col, column := range columns {
+		value := vector[col].Int64()
+		if col < len(mains) && ((column.coefficient > 0 && value <= 0) || value < 0) {
+			return nil, 0, failure("half-reaction of %s cannot be balanced")
+		}
+		if value == 0 {
+			continue
+		}
+		signed := column.coefficient * value
+		if column.species.key == electronKey {
+			electrons = signed * electronSign
+		}
+		terms = append(terms, redoxTerm{species: column.species, coefficient: signed})
+	}
+	if electrons <= 0 {
+		return nil, 0, failure("half-reaction of %s does not transfer electrons as expected")
+	}
+	return terms, electrons, nil
+}
+
+// halfNullspace builds the composition matrix of a half-reaction, with a
+// charge row, and returns its columns and the integer solution oriented so
+// that the first reagent is positive. The solution is nil unless the
+// nullspace is one-dimensional.
+func halfNullspace(mains []redoxTerm, helpers []redoxSpecies) ([]redoxTerm, []*big.Int) {
+	columns := append([]redoxTerm{}, mains...)
+	present := make(map[string]bool)
+	for _, term := range mains {
+		present[term.species.key] = true
+	}
+	for _, helper := range helpers {
+		if !present[helper.key] {
+			columns = append(columns, redoxTerm{species: helper, coefficient: -1})
+		}
+	}
+
+	rows := make(map[string]int)
+	for _, column := range columns {
+		for symbol := range column.species.compound.Data {
+			if _, ok := rows[symbol]; !ok {
+				rows[symbol] = len(rows)
+			}
+		}
+	}
+	rows[chargeRow] = len(rows)
+
+	matrix := newRatMatrix(len(rows), len(columns))
+	for col, column := range columns {
+		for symbol, count := range column.species.compound.Data {
+			matrix[rows[symbol]][col].SetInt64(column.coefficient * int64(count))
+		}
+		matrix[rows[chargeRow]][col].SetInt64(column.coefficient * int64(column.species.compound.Charge))
+	}
+
+	basis, _ := matrix.nullspace(nil)
+	if len(basis) != 1 {
+		return columns, nil
+	}
+	vector := integerVector(basis[0])
+	if vector[0].Sign() < 0 {
+		for _, value := range vector {
+			value.Neg(value)
+		}
+	}
+	return columns, vector
+}
+
+// formatTerms renders signed terms as an equation.
+func formatTerms(terms []redoxTerm) string {
+	equation := Equation{}
+	var reagentCoefficients, productCoefficients []int
+	for _, term := range terms {
+		coefficient := term.coefficient
+		if coefficient > 0 {
+			equation.Reagents = append(equation.Reagents, term.species.formula)
+			reagentCoefficients = append(reagentCoefficients, int(coefficient))
+		} else {
+			equation.Products = append(equation.Products, term.species.formula)
+			productCoefficients = append(productCoefficients, int(-coefficient))
+		}
+	}
+	return equation.Format(append(reagentCoefficients, productCoefficients...))
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func lcm(a, b int64) int64 {
+	return a / gcd(a, b) * b
+}
+
+func abs(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
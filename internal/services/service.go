@@ -4,8 +4,6 @@ import (
 	"ChemistryPR/internal/database"
 	"ChemistryPR/internal/models"
 	"fmt"
-	"regexp"
-	"strconv"
 	"strings"
 )
 
@@ -19,75 +17,91 @@ type ChemicalService struct {
 	Store database.Store
 }
 
+// InputError is an error caused by the values of a request, such as a
+// missing or negative quantity or a species that is not in the equation,
+// rather than by a failure of the store or of a balancer backend. Malformed
+// formulas and equations are reported as *ParseError, *UnknownElementError
+// and *BalanceError instead.
+type InputError struct {
+	Err error
+}
+
+func (err *InputError) Error() string {
+	return err.Err.Error()
+}
+
+func (err *InputError) Unwrap() error {
+	return err.Err
+}
+
+// inputErrorf formats an InputError as fmt.Errorf does.
+func inputErrorf(format string, args ...any) error {
+	return &InputError{Err: fmt.Errorf(format, args...)}
+}
+
 // ParseCompound parses a chemical formula and returns a Compound object
 // along with any potential error encountered during the parsing process.
 //
-// The function utilizes regular expressions to handle nested groups
-// and individual elements in the formula. It expands any grouped
-// elements according to their multipliers and counts the occurrences
-// of each element in the entire formula.
+// The formula is split into tokens and read by a recursive-descent parser,
+// so groups may be nested in "()", "[]" and "{}" to any depth and every
+// character of the input must belong to the formula: stray characters,
+// lower-case symbols and unbalanced brackets are reported instead of being
+// skipped.
+//
+// Isotope-labelled atoms are written with the mass number and symbol in
+// square brackets, as in "[13C]H4" or "CH3[2H]", or as "D" and "T" for
+// hydrogen-2 and hydrogen-3. They are counted in Data under their element
+// and, by nuclide, in Isotopes.
+//
+// Functional-group abbreviations from the abbreviations table, such as
+// "Ph" in "PhCOOH", "tBu" in "tBuOK" or "OAc" in "NaOAc", are expanded
+// into their formulas before counting and the result is returned in
+// Expanded. Element symbols are never read as abbreviations, so "PrCl3" is
+// praseodymium chloride; propyl is written "nPr" or "iPr".
+//
+// A species prefixed with "smiles:", as in "smiles:CCO", is read as SMILES
+// instead: atoms, bonds, branches, ring closures, aromatic atoms and bracket
+// atoms, with implicit hydrogens added from the default valences.
+//
+// Hydrates and adducts are written as parts joined by "·", "•", "*" or
+// ".", each after the first with an optional leading multiplier, as in
+// "CuSO4·5H2O" or "Na2CO3.10H2O". The parts are returned in Adducts and
+// their counts are summed into Data.
+//
+// A trailing charge is recognised in the forms "^2+", "^+2", "^-", "+3",
+// "-" or "++": a caret followed by a magnitude and a sign in either order,
+// or a run of signs optionally followed by a magnitude. The free electron is
+// written "e-" (or "e^-") and parses to a compound without elements and a
+// charge of -1. A state label "(g)", "(l)", "(s)" or "(aq)" may end the
+// species, after any charge, and is returned in State.
 //
 // Arguments:
 //   - formula: A string representing the chemical formula to parse.
 //
 // Returns:
-//   - models.Compound: A structure containing the final formula and
-//     a map of elements with their respective counts.
-//   - error: An error value that will be non-nil if any issues were
-//     encountered during parsing, including unknown elements or
-//     conversion errors.
+//   - models.Compound: A structure containing the formula without its
+//     charge and state label, a map of elements with their respective
+//     counts, the charge, the state, the labelled atoms and, for hydrates
+//     and adducts, the dot-joined parts.
+//   - error: A *ParseError with the column of the offending token and the
+//     token the parser expected there when the formula is malformed.
 func (service ChemicalService) ParseCompound(formula string) (models.Compound, error) {
-	var err error
-	elementCounts := make(map[string]int)
-
-	elementPattern := regexp.MustCompile(`([A-Z][a-z]*)(\d*)`)
-	groupPattern := regexp.MustCompile(`\(([^()]+)\)(\d*)`)
-
-	for {
-		matches := groupPattern.FindStringSubmatch(formula)
-		if matches == nil {
-			break
-		}
-
-		group := matches[1]
-		multiplier := 1
-		if matches[2] != "" {
-			multiplier, err = strconv.Atoi(matches[2])
-			if err != nil {
-				return models.Compound{}, err
-			}
-		}
-
-		expanded := ""
-		for _, match := range elementPattern.FindAllStringSubmatch(group, -1) {
-			element := match[1]
-			count := 1
-			if match[2] != "" {
-				count, err = strconv.Atoi(match[2])
-				if err != nil {
-					return models.Compound{}, err
-				}
-			}
-			expanded += fmt.Sprintf("%s%d", element, count*multiplier)
-		}
-
-		formula = strings.Replace(formula, matches[0], expanded, 1)
+	formula = strings.TrimSpace(formula)
+	if strings.HasPrefix(formula, SMILESPrefix) {
+		return parseSMILES(formula)
 	}
-
-	for _, match := range elementPattern.FindAllStringSubmatch(formula, -1) {
-		element := match[1]
-		count := 1
-		if match[2] != "" {
-			count, err = strconv.Atoi(match[2])
-			if err != nil {
-				return models.Compound{}, err
-			}
-		}
-		elementCounts[element] += count
+	abbreviations, err := service.abbreviations()
+	if err != nil {
+		return models.Compound{}, err
 	}
+	return parseFormula(formula, abbreviations)
+}
 
-	return models.Compound{
-		Formula: formula,
-		Data:    elementCounts,
-	}, nil
+// abbreviations loads the functional-group abbreviations from the store.
+// A service without a database knows none.
+func (service ChemicalService) abbreviations() (map[string]string, error) {
+	if service.Store.DB == nil {
+		return nil, nil
+	}
+	return service.Store.GetAbbreviations()
 }
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"ChemistryPR/internal/database"
+	"ChemistryPR/internal/units"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// testService opens the committed database read-only for tests that need
+// element weights, abbreviations or compound records.
+func testService(t *testing.T) ChemicalService {
+	t.Helper()
+	db, closeFunc, err := database.OpenDB("sqlite", "file:../../database/chem.db?mode=ro")
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	t.Cleanup(closeFunc)
+	return ChemicalService{Store: database.NewStore(db)}
+}
+
+// parseQuantity parses text, an empty string giving the zero quantity.
+func parseQuantity(t *testing.T, text string) units.Quantity {
+	t.Helper()
+	if text == "" {
+		return units.Quantity{}
+	}
+	quantity, err := units.Parse(text)
+	if err != nil {
+		t.Fatalf("units.Parse(%q): %v", text, err)
+	}
+	return quantity
+}
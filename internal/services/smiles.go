@@ -0,0 +1,371 @@
+package services
+
+import (
+	"ChemistryPR/internal/models"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SMILESPrefix marks a species written as SMILES rather than as a formula,
+// as in "smiles:CCO".
+const SMILESPrefix = "smiles:"
+
+// smilesValences are the default valences of the organic subset, used to
+// add implicit hydrogens to atoms written outside brackets.
+var smilesValences = map[string][]int{
+	"B": {3}, "C": {4}, "N": {3, 5}, "O": {2}, "P": {3, 5}, "S": {2, 4, 6},
+	"F": {1}, "Cl": {1}, "Br": {1}, "I": {1},
+}
+
+// aromaticValences are the valences of aromatic atoms written in lower
+// case, one less than the plain valence to account for the delocalised
+// bond: benzene's "c" takes one hydrogen, pyridine's "n" none.
+var aromaticValences = map[string][]int{
+	"b": {2}, "c": {3}, "n": {2}, "o": {2}, "p": {2}, "s": {2},
+}
+
+// bracketAromatics are the aromatic symbols allowed in brackets only.
+var bracketAromatics = []string{"se", "as"}
+
+// smilesBonds maps the bond symbols to bond orders. Aromatic and
+// stereo-specific bonds count as single bonds.
+var smilesBonds = map[rune]int{'-': 1, '=': 2, '#': 3, '$': 4, ':': 1, '/': 1, '\\': 1}
+
+// smilesAtom is an atom of a SMILES string.
+type smilesAtom struct {
+	symbol    string // Element symbol as written, lower case when aromatic
+	bracket   bool   // Written in brackets, so that hydrogens are explicit
+	hydrogens int    // Hydrogens written in brackets
+	charge    int
+	isotope   int // Mass number written in brackets, zero when unlabelled
+	bondSum   int // Sum of the orders of the bonds to other atoms
+}
+
+// ringBond is a ring-closure digit waiting for its partner.
+type ringBond struct {
+	atom   int
+	order  int // Order written at the opening digit, zero when none
+	column int
+}
+
+// smilesParser reads a SMILES string into atoms and bonds:
+//
+//	chain   = atom { [bond] ( atom | ring ) | "(" [bond] chain ")" | "." atom }
+//	atom    = organic | aromatic | "[" [count] symbol [chiral] ["H" [count]] [charge] [":" count] "]"
+//	ring    = digit | "%" digit digit
+//
+// Implicit hydrogens of organic-subset atoms are added from their default
+// valences once the whole string is read.
+type smilesParser struct {
+	input    []rune
+	position int
+	atoms    []smilesAtom
+	rings    map[int]ringBond
+}
+
+// parseSMILES parses a species written as "smiles:..." into a compound with
+// the same element counts a molecular formula would give; Expanded holds
+// that molecular formula. Columns of parse errors count from the start of
+// the prefix.
+func parseSMILES(formula string) (models.Compound, error) {
+	parser := &smilesParser{
+		input:    []rune(formula),
+		position: len([]rune(SMILESPrefix)),
+		rings:    make(map[int]ringBond),
+	}
+	if err := parser.chain(); err != nil {
+		return models.Compound{}, err
+	}
+
+	compound := models.Compound{
+		Formula:  formula,
+		Data:     make(map[string]int),
+		Isotopes: make(map[models.Nuclide]int),
+	}
+	for _, atom := range parser.atoms {
+		symbol := strings.ToUpper(atom.symbol[:1]) + atom.symbol[1:]
+		compound.Data[symbol]++
+		compound.Charge += atom.charge
+		if atom.isotope != 0 {
+			compound.Isotopes[models.Nuclide{Symbol: symbol, MassNumber: atom.isotope}]++
+		}
+		if hydrogens := atom.implicitHydrogens() + atom.hydrogens; hydrogens > 0 {
+			compound.Data["H"] += hydrogens
+		}
+	}
+	compound.Expanded = CanonicalFormula(compound)
+	return compound, nil
+}
+
+// implicitHydrogens returns the hydrogens an organic-subset atom needs to
+// reach the smallest default valence not below its bond sum.
+func (atom smilesAtom) implicitHydrogens() int {
+	if atom.bracket {
+		return 0
+	}
+	valences := smilesValences[atom.symbol]
+	if unicode.IsLower(rune(atom.symbol[0])) {
+		valences = aromaticValences[atom.symbol]
+	}
+	for _, valence := range valences {
+		if valence >= atom.bondSum {
+			return valence - atom.bondSum
+		}
+	}
+	return 0
+}
+
+// peek returns the current character or zero at the end of the input.
+func (parser *smilesParser) peek() rune {
+	if parser.position >= len(parser.input) {
+		return 0
+	}
+	return parser.input[parser.position]
+}
+
+// fail builds a ParseError at the current position.
+func (parser *smilesParser) fail(expected string) error {
+	return parser.failAt(parser.position+1, expected)
+}
+
+// failAt builds a ParseError at the given 1-based column.
+func (parser *smilesParser) failAt(column int, expected string) error {
+	found := "end of formula"
+	if column <= len(parser.input) {
+		found = strconv.Quote(string(parser.input[column-1]))
+	}
+	return &ParseError{Formula: string(parser.input), Column: column, Expected: expected, Found: found}
+}
+
+// bond connects two atoms with a bond of the given order.
+func (parser *smilesParser) bond(a, b, order int) {
+	parser.atoms[a].bondSum += order
+	parser.atoms[b].bondSum += order
+}
+
+// chain reads the whole string. previous is the atom the next one bonds
+// to, or -1 at the start and after a ".".
+func (parser *smilesParser) chain() error {
+	previous := -1
+	var branches []int
+	order := 0
+	orderColumn := 0
+
+	for parser.position < len(parser.input) {
+		column := parser.position + 1
+		next := parser.peek()
+		switch {
+		case next == '(':
+			if previous < 0 || order != 0 {
+				return parser.fail("atom")
+			}
+			branches = append(branches, previous)
+			parser.position++
+		case next == ')':
+			if len(branches) == 0 || order != 0 {
+				return parser.fail("atom")
+			}
+			previous = branches[len(branches)-1]
+			branches = branches[:len(branches)-1]
+			parser.position++
+		case smilesBonds[next] != 0:
+			if previous < 0 || order != 0 {
+				return parser.fail("atom")
+			}
+			order, orderColumn = smilesBonds[next], column
+			parser.position++
+		case next == '.':
+			if previous < 0 || order != 0 || len(branches) != 0 {
+				return parser.fail("atom")
+			}
+			previous = -1
+			parser.position++
+		case unicode.IsDigit(next) || next == '%':
+			if previous < 0 {
+				return parser.fail("atom")
+			}
+			if err := parser.ring(previous, order, column); err != nil {
+				return err
+			}
+			order = 0
+		default:
+			atom, err := parser.atom()
+			if err != nil {
+				return err
+			}
+			parser.atoms = append(parser.atoms, atom)
+			current := len(parser.atoms) - 1
+			if previous >= 0 {
+				parser.bond(previous, current, max(order, 1))
+			}
+			previous, order = current, 0
+		}
+	}
+
+	switch {
+	case len(parser.atoms) == 0:
+		return parser.fail("atom")
+	case order != 0:
+		return parser.failAt(orderColumn, "atom after bond")
+	case len(branches) != 0:
+		return parser.fail(`")"`)
+	}
+	unclosed := 0
+	for _, ring := range parser.rings {
+		if unclosed == 0 || ring.column < unclosed {
+			unclosed = ring.column
+		}
+	}
+	if unclosed != 0 {
+		return parser.failAt(unclosed, "ring closure with a matching digit")
+	}
+	return nil
+}
+
+// ring opens or closes the ring bond whose number starts at the current
+// position.
+func (parser *smilesParser) ring(atom, order, column int) error {
+	var number int
+	if parser.peek() == '%' {
+		parser.position++
+		if parser.position+2 > len(parser.input) ||
+			!unicode.IsDigit(parser.input[parser.position]) || !unicode.IsDigit(parser.input[parser.position+1]) {
+			return parser.fail("two-digit ring number")
+		}
+		number, _ = strconv.Atoi(string(parser.input[parser.position : parser.position+2]))
+		parser.position += 2
+	} else {
+		number = int(parser.peek() - '0')
+		parser.position++
+	}
+
+	open, ok := parser.rings[number]
+	if !ok {
+		parser.rings[number] = ringBond{atom: atom, order: order, column: column}
+		return nil
+	}
+	delete(parser.rings, number)
+	if open.atom == atom {
+		return parser.failAt(column, "ring closure on another atom")
+	}
+	if open.order != 0 && order != 0 && open.order != order {
+		return parser.failAt(column, "bond matching the ring opening")
+	}
+	parser.bond(open.atom, atom, max(open.order, order, 1))
+	return nil
+}
+
+// atom reads an organic-subset, aromatic or bracket atom.
+func (parser *smilesParser) atom() (smilesAtom, error) {
+	if parser.peek() == '[' {
+		return parser.bracketAtom()
+	}
+	rest := string(parser.input[parser.position:])
+	for _, symbol := range []string{"Cl", "Br"} {
+		if strings.HasPrefix(rest, symbol) {
+			parser.position += 2
+			return smilesAtom{symbol: symbol}, nil
+		}
+	}
+	symbol := string(parser.peek())
+	if smilesValences[symbol] == nil && aromaticValences[symbol] == nil {
+		return smilesAtom{}, parser.fail("atom, bond, branch or ring closure")
+	}
+	parser.position++
+	return smilesAtom{symbol: symbol}, nil
+}
+
+// bracketAtom reads an atom in square brackets with its optional isotope,
+// chirality, hydrogen count, charge and atom class.
+func (parser *smilesParser) bracketAtom() (smilesAtom, error) {
+	atom := smilesAtom{bracket: true}
+	parser.position++
+
+	if unicode.IsDigit(parser.peek()) {
+		atom.isotope = parser.number()
+	}
+
+	rest := string(parser.input[parser.position:])
+	for _, symbol := range bracketAromatics {
+		if strings.HasPrefix(rest, symbol) {
+			atom.symbol = symbol
+		}
+	}
+	switch next := parser.peek(); {
+	case atom.symbol != "":
+		parser.position += len(atom.symbol)
+	case unicode.IsUpper(next):
+		start := parser.position
+		parser.position++
+		if unicode.IsLower(parser.peek()) {
+			parser.position++
+		}
+		atom.symbol = string(parser.input[start:parser.position])
+	case aromaticValences[string(next)] != nil:
+		atom.symbol = string(next)
+		parser.position++
+	default:
+		return smilesAtom{}, parser.fail("element symbol")
+	}
+
+	for parser.peek() == '@' {
+		parser.position++
+	}
+	if rest := string(parser.input[parser.position:]); len(rest) > 2 && unicode.IsDigit(rune(rest[2])) {
+		for _, class := range []string{"TH", "AL", "SP", "TB", "OH"} {
+			if strings.HasPrefix(rest, class) {
+				parser.position += 2
+				parser.number()
+			}
+		}
+	}
+
+	if parser.peek() == 'H' {
+		parser.position++
+		atom.hydrogens = 1
+		if unicode.IsDigit(parser.peek()) {
+			atom.hydrogens = parser.number()
+		}
+	}
+
+	if sign := parser.peek(); sign == '+' || sign == '-' {
+		direction := 1
+		if sign == '-' {
+			direction = -1
+		}
+		parser.position++
+		atom.charge = direction
+		if unicode.IsDigit(parser.peek()) {
+			atom.charge = direction * parser.number()
+		}
+		for parser.peek() == sign {
+			atom.charge += direction
+			parser.position++
+		}
+	}
+
+	if parser.peek() == ':' {
+		parser.position++
+		if !unicode.IsDigit(parser.peek()) {
+			return smilesAtom{}, parser.fail("atom class")
+		}
+		parser.number()
+	}
+
+	if parser.peek() != ']' {
+		return smilesAtom{}, parser.fail(`"]"`)
+	}
+	parser.position++
+	return atom, nil
+}
+
+// number reads a run of digits.
+func (parser *smilesParser) number() int {
+	start := parser.position
+	for unicode.IsDigit(parser.peek()) {
+		parser.position++
+	}
+	value, _ := strconv.Atoi(string(parser.input[start:parser.position]))
+	return value
+}
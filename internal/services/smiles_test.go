@@ -0,0 +1,66 @@
+package services
+
+import (
+	"errors"
+	"maps"
+	"testing"
+)
+
+func TestParseSMILES(t *testing.T) {
+	tests := []struct {
+		smiles string
+		data   map[string]int
+		charge int
+	}{
+		{smiles: "CCO", data: map[string]int{"C": 2, "H": 6, "O": 1}},
+		{smiles: "C=C", data: map[string]int{"C": 2, "H": 4}},
+		{smiles: "C#N", data: map[string]int{"C": 1, "H": 1, "N": 1}},
+		{smiles: "O=C=O", data: map[string]int{"C": 1, "O": 2}},
+		{smiles: "CC(=O)O", data: map[string]int{"C": 2, "H": 4, "O": 2}},
+		{smiles: "c1ccccc1", data: map[string]int{"C": 6, "H": 6}},
+		{smiles: "c1ccncc1", data: map[string]int{"C": 5, "H": 5, "N": 1}},
+		{smiles: "C1CCCCC1", data: map[string]int{"C": 6, "H": 12}},
+		{smiles: "CC(=O)Oc1ccccc1C(=O)O", data: map[string]int{"C": 9, "H": 8, "O": 4}},
+		{smiles: "Cl", data: map[string]int{"Cl": 1, "H": 1}},
+		{smiles: "[NH4+]", data: map[string]int{"N": 1, "H": 4}, charge: 1},
+		{smiles: "[13CH4]", data: map[string]int{"C": 1, "H": 4}},
+		{smiles: "[Na+].[Cl-]", data: map[string]int{"Na": 1, "Cl": 1}},
+	}
+
+	for _, test := range tests {
+		compound, err := parseSMILES(SMILESPrefix + test.smiles)
+		if err != nil {
+			t.Errorf("parseSMILES(%q): %v", test.smiles, err)
+			continue
+		}
+		if !maps.Equal(compound.Data, test.data) || compound.Charge != test.charge {
+			t.Errorf("parseSMILES(%q) = %v charge %d, want %v charge %d", test.smiles, compound.Data, compound.Charge, test.data, test.charge)
+		}
+	}
+}
+
+func TestParseSMILESErrors(t *testing.T) {
+	tests := []struct {
+		smiles   string
+		column   int
+		expected string
+	}{
+		{smiles: "C1CC", column: 9, expected: "ring closure with a matching digit"},
+		{smiles: "C(C", column: 11, expected: `")"`},
+		{smiles: "C[", column: 10, expected: "element symbol"},
+		{smiles: "CX", column: 9, expected: "atom, bond, branch or ring closure"},
+	}
+
+	for _, test := range tests {
+		_, err := parseSMILES(SMILESPrefix + test.smiles)
+		var parseError *ParseError
+		if !errors.As(err, &parseError) {
+			t.Errorf("parseSMILES(%q) error = %v, want a *ParseError", test.smiles, err)
+			continue
+		}
+		if parseError.Column != test.column || parseError.Expected != test.expected {
+			t.Errorf("parseSMILES(%q) error at column %d: expected %s; want column %d: expected %s",
+				test.smiles, parseError.Column, parseError.Expected, test.column, test.expected)
+		}
+	}
+}
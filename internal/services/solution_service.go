@@ -0,0 +1,367 @@
+package services
+
+import (
+	"ChemistryPR/internal/units"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SolutionService converts the concentration of an aqueous solution
+// between its usual measures, works out how to prepare a volume of it and
+// solves dilutions. It embeds the ChemicalService to weigh the solute and
+// to look up its density in the compounds table.
+type SolutionService struct {
+	ChemicalService
+}
+
+// SolutionInput holds the data of a solution problem.
+type SolutionInput struct {
+	Formula       string         // Solute; the solvent is water
+	Concentration units.Quantity // In M, mol/kg, %, ppm or N, its measure read from its unit; a bare number is a mole fraction
+	Density       units.Quantity // Density of the solution, g/mL for a bare number; unknown when zero
+	RecordDensity bool           // Use the density in the compound record of the solute when Density is zero
+	Equivalents   int            // Equivalents per mole of solute for the normality, 1 when zero
+	Volume        units.Quantity // Volume of solution to prepare, mL for a bare number; zero for no recipe
+	Dilution      Dilution
+}
+
+// Dilution is a C1V1 = C2V2 problem: a volume of stock solution diluted
+// with water to a larger volume of a lower concentration. Concentrations
+// are in M or N, M for a bare number, and volumes mL for a bare number;
+// zero values are unknown.
+type Dilution struct {
+	Initial       units.Quantity // Concentration of the stock solution
+	InitialVolume units.Quantity // Volume of the stock solution taken
+	Final         units.Quantity // Concentration of the diluted solution
+	FinalVolume   units.Quantity // Volume of the diluted solution
+}
+
+// SolutionResponse is the solution of a solution problem. A measure that
+// needs the density is empty when the density of the solution is unknown.
+type SolutionResponse struct {
+	Formula      string
+	MolarMass    string          // Molar mass of the solute, e.g. "58.44 g/mol"
+	Density      string          // Density of the solution as given or taken from the compound record, empty when unknown
+	Molarity     string          // Moles of solute per litre of solution, e.g. "0.100 M"
+	Molality     string          // Moles of solute per kilogram of water, e.g. "0.101 mol/kg"
+	MassFraction string          // Mass of solute per mass of solution, e.g. "0.583 %"
+	MoleFraction string          // Moles of solute per moles of solute and water
+	PPM          string          // Mass fraction in parts per million, e.g. "5830 ppm"
+	Normality    string          // Equivalents of solute per litre of solution, e.g. "0.200 N"
+	Recipe       *SolutionRecipe // How to prepare SolutionInput.Volume, nil without a volume
+	Dilution     *DilutionInfo   // The solved dilution, nil without one
+	Equivalents  string          // Equivalents per mole used for Normality
+	Warnings     []string        // Why some measures are missing or approximate, e.g. when only the pure solute's density is known
+}
+
+// SolutionRecipe says how to prepare a volume of the solution: dissolve
+// Mass of the solute in water and make it up to Volume.
+type SolutionRecipe struct {
+	Volume       string // Volume of solution to prepare
+	Mass         string // Mass of solute to dissolve
+	SoluteVolume string // Volume of the pure liquid solute with that mass, empty when its density is unknown
+}
+
+// DilutionInfo is a dilution with its unknown filled in.
+type DilutionInfo struct {
+	Solved        string // Variable solved for: "C1", "V1", "C2" or "V2"
+	Initial       string
+	InitialVolume string
+	Final         string
+	FinalVolume   string
+	Water         string // Volume of water to add to the stock solution, V2 - V1
+}
+
+// solutionSolvent is the solvent of every solution.
+const solutionSolvent = "H2O"
+
+// GetResponse converts input.Concentration of input.Formula into every
+// measure of concentration, prepares input.Volume of the solution and
+// solves input.Dilution, each when given.
+//
+// Molarity and normality are per volume of solution and the other measures
+// per mass, so converting between the two kinds takes the density of the
+// solution. The density in the compound record of the solute is that of
+// the pure liquid, 1.83 g/mL for H2SO4 where its 1.00 M solution has 1.06,
+// so it stands in for the density of the solution only when
+// input.RecordDensity asks for it, with a warning. Otherwise the measures
+// of the other kind are left empty and the record density is used only for
+// the volume of liquid solute in the recipe.
+//
+// Molar masses of the solute and of water are computed as MolarMassService
+// does. Results keep the significant figures of the least precise value
+// they come from.
+func (service SolutionService) GetResponse(input SolutionInput, precision Precision) (SolutionResponse, error) {
+	response := SolutionResponse{Formula: strings.TrimSpace(input.Formula)}
+	if err := precision.Validate(); err != nil {
+		return response, err
+	}
+	if input.Dilution != (Dilution{}) {
+		dilution, err := solveDilution(input.Dilution, precision)
+		if err != nil {
+			return response, err
+		}
+		response.Dilution = dilution
+	}
+	if response.Formula == "" {
+		switch {
+		case input.Concentration != (units.Quantity{}) || input.Volume != (units.Quantity{}):
+			return response, inputErrorf("name the solute to convert its concentration")
+		case response.Dilution == nil:
+			return response, inputErrorf("give a solute and its concentration, or a dilution")
+		}
+		return response, nil
+	}
+	if input.Concentration == (units.Quantity{}) {
+		return response, inputErrorf("give the concentration of %s", response.Formula)
+	}
+
+	solute, soluteDensity, err := service.solute(response.Formula)
+	if err != nil {
+		return response, err
+	}
+	water, _, err := service.solute(solutionSolvent)
+	if err != nil {
+		return response, err
+	}
+	response.MolarMass = units.Join(precision.FormatExact(solute.molarMass, solute.places), units.GramPerMole)
+	equivalents := input.Equivalents
+	if equivalents == 0 {
+		equivalents = 1
+	}
+	if equivalents < 0 {
+		return response, inputErrorf("equivalents per mole must be positive, got %d", equivalents)
+	}
+	response.Equivalents = fmt.Sprint(equivalents)
+
+	var density units.Quantity
+	if input.Density != (units.Quantity{}) {
+		if density, err = input.Density.Default(units.GramPerMillilitre).In(units.GramPerMillilitre); err != nil {
+			return response, inputErrorf("density: %w", err)
+		}
+		if density.Value <= 0 {
+			return response, inputErrorf("density must be positive, got %s", input.Density)
+		}
+	}
+	if density.Value == 0 && soluteDensity.Value > 0 {
+		pure := formatQuantity(precision, soluteDensity.Value, soluteDensity.Figures)
+		if input.RecordDensity {
+			density = soluteDensity
+			response.Warnings = append(response.Warnings, fmt.Sprintf(
+				"%s g/mL is the density of pure %s, not of its solutions; measures converted with it are approximate except for concentrated solutions",
+				pure, response.Formula))
+		} else {
+			response.Warnings = append(response.Warnings, fmt.Sprintf(
+				"%s g/mL is the density of pure %s, not of its solutions; give the density of the solution to convert between measures per volume and per mass",
+				pure, response.Formula))
+		}
+	}
+	if density.Value > 0 {
+		response.Density = units.Join(formatQuantity(precision, density.Value, density.Figures), units.GramPerMillilitre)
+	}
+
+	// The mass fraction of the solute carries the measures per mass and
+	// the molarity those per volume; the density links the two.
+	concentration := input.Concentration
+	if concentration.Value < 0 {
+		return response, inputErrorf("concentration must not be negative, got %s", concentration)
+	}
+	var fraction, molarity float64
+	var fractionFigures, molarityFigures int
+	knownFraction, knownMolarity := false, false
+	switch concentration.Unit.Dimension {
+	case units.Fraction:
+		percent, _ := concentration.In(units.Percent)
+		fraction, fractionFigures, knownFraction = percent.Value/100, percent.Figures, true
+	case units.Molality:
+		molal, _ := concentration.In(units.Molal)
+		solutePerWater := molal.Value * solute.molarValue / 1000
+		fraction, fractionFigures, knownFraction = solutePerWater/(1+solutePerWater), min(molal.Figures, solute.molarFigures), true
+	case units.Dimensionless:
+		if concentration.Value > 1 {
+			return response, inputErrorf("mole fraction must not exceed 1, got %s; write a unit for other measures", concentration)
+		}
+		soluteMass := concentration.Value * solute.molarValue
+		fraction = soluteMass / (soluteMass + (1-concentration.Value)*water.molarValue)
+		fractionFigures, knownFraction = min(concentration.Figures, solute.molarFigures, water.molarFigures), true
+	case units.Concentration:
+		molar, _ := concentration.In(units.Molar)
+		molarity, molarityFigures, knownMolarity = molar.Value, molar.Figures, true
+	case units.Normality:
+		normal, _ := concentration.In(units.Normal)
+		molarity, molarityFigures, knownMolarity = normal.Value/float64(equivalents), normal.Figures, true
+	default:
+		return response, inputErrorf("%w: %s is not a concentration in M, mol/kg, %%, ppm or N, or a mole fraction", units.ErrDimension, concentration)
+	}
+	if density.Value > 0 {
+		if knownFraction {
+			molarity = fraction * density.Value * 1000 / solute.molarValue
+			molarityFigures, knownMolarity = min(fractionFigures, density.Figures, solute.molarFigures), true
+		} else {
+			fraction = molarity * solute.molarValue / (density.Value * 1000)
+			fractionFigures, knownFraction = min(molarityFigures, density.Figures, solute.molarFigures), true
+		}
+	}
+	if knownFraction && fraction >= 1 {
+		return response, inputErrorf("%s of %s leaves no room for water in the solution", concentration, response.Formula)
+	}
+
+	if knownFraction {
+		soluteMoles := fraction / solute.molarValue
+		waterMoles := (1 - fraction) / water.molarValue
+		figures := min(fractionFigures, solute.molarFigures)
+		response.MassFraction = units.Join(formatQuantity(precision, fraction*100, fractionFigures), units.Percent)
+		response.PPM = units.Join(formatQuantity(precision, fraction*1e6, fractionFigures), units.PartsPerMillion)
+		response.Molality = units.Join(formatQuantity(precision, soluteMoles/(1-fraction)*1000, figures), units.Molal)
+		response.MoleFraction = formatQuantity(precision, soluteMoles/(soluteMoles+waterMoles), min(figures, water.molarFigures))
+	}
+	if knownMolarity {
+		response.Molarity = units.Join(formatQuantity(precision, molarity, molarityFigures), units.Molar)
+		response.Normality = units.Join(formatQuantity(precision, molarity*float64(equivalents), molarityFigures), units.Normal)
+	}
+
+	if input.Volume != (units.Quantity{}) {
+		if !knownMolarity {
+			return response, inputErrorf("give the density of the solution to prepare a volume of it")
+		}
+		volume := input.Volume.Default(units.Millilitre)
+		litres, err := volume.In(units.Litre)
+		if err != nil {
+			return response, inputErrorf("volume: %w", err)
+		}
+		if litres.Value <= 0 {
+			return response, inputErrorf("volume must be positive, got %s", input.Volume)
+		}
+		moles := molarity * litres.Value
+		figures := min(molarityFigures, litres.Figures)
+		recipe := &SolutionRecipe{
+			Volume: units.Join(formatQuantity(precision, volume.Value, volume.Figures), volume.Unit),
+			Mass:   solute.grams(precision, moles, figures),
+		}
+		if soluteDensity.Value > 0 {
+			mass := moles * solute.molarValue
+			recipe.SoluteVolume = units.Join(formatQuantity(precision, mass/soluteDensity.Value,
+				min(figures, solute.molarFigures, soluteDensity.Figures)), units.Millilitre)
+		}
+		response.Recipe = recipe
+	}
+	return response, nil
+}
+
+// solute parses formula, computes its molar mass and looks up the density
+// of the pure compound, which is the zero quantity when the compounds
+// table has none.
+func (service SolutionService) solute(formula string) (reactionSpecies, units.Quantity, error) {
+	compound, err := service.ParseCompound(formula)
+	if err != nil {
+		return reactionSpecies{}, units.Quantity{}, err
+	}
+	if compound.Charge != 0 {
+		return reactionSpecies{}, units.Quantity{}, inputErrorf("%s is an ion; give the salt that is dissolved", formula)
+	}
+	weight, places, err := MolarMassService{service.ChemicalService}.molarMass(compound)
+	if err != nil {
+		return reactionSpecies{}, units.Quantity{}, err
+	}
+	value, _ := weight.Float64()
+	species := reactionSpecies{
+		formula:      formula,
+		molarMass:    weight,
+		places:       places,
+		molarValue:   value,
+		molarFigures: significantPlaces(value, places),
+	}
+	record, err := service.Store.GetCompound(CanonicalFormula(compound))
+	if err != nil || record.Density == 0 {
+		return species, units.Quantity{}, err
+	}
+	_, densityPlaces := exactDecimal(record.Density)
+	density := units.Quantity{
+		Value:   record.Density,
+		Unit:    units.GramPerMillilitre,
+		Figures: significantPlaces(record.Density, densityPlaces),
+	}
+	return species, density, nil
+}
+
+// solveDilution fills in the one unknown of dilution from C1V1 = C2V2.
+// The solved variable is written in the unit of its counterpart, and the
+// water to add in the unit of the final volume.
+func solveDilution(dilution Dilution, precision Precision) (*DilutionInfo, error) {
+	names := []string{"C1", "V1", "C2", "V2"}
+	quantities := []units.Quantity{dilution.Initial, dilution.InitialVolume, dilution.Final, dilution.FinalVolume}
+	unknown := -1
+	for i, quantity := range quantities {
+		if quantity == (units.Quantity{}) {
+			if unknown >= 0 {
+				return nil, inputErrorf("give exactly three of C1, V1, C2 and V2, %s and %s are missing", names[unknown], names[i])
+			}
+			unknown = i
+			continue
+		}
+		base := units.Millilitre
+		if i%2 == 0 {
+			base = units.Molar
+		}
+		quantities[i] = quantity.Default(base)
+		if dimension := quantities[i].Unit.Dimension; i%2 == 0 && dimension != units.Concentration && dimension != units.Normality ||
+			i%2 == 1 && dimension != units.Volume {
+			return nil, inputErrorf("%w: %s must be %s, got %s", units.ErrDimension, names[i], describeDilution(i), quantity)
+		}
+		if quantity.Value <= 0 {
+			return nil, inputErrorf("%s must be positive, got %s", names[i], quantity)
+		}
+	}
+	if unknown < 0 {
+		return nil, inputErrorf("give exactly three of C1, V1, C2 and V2, leave out the one to find")
+	}
+
+	// The counterpart of a variable sits across C1V1 = C2V2 from it, C1
+	// from C2 and V1 from V2, and gives the solved variable its unit. The
+	// partner shares its side, and must be in the unit of the other
+	// variable of its kind for the two to cancel.
+	counterpart, partner := (unknown+2)%4, unknown^1
+	other := counterpart ^ 1
+	partnerValue, err := quantities[partner].In(quantities[other].Unit)
+	if err != nil {
+		return nil, inputErrorf("%s and %s must be in the same measure: %w", names[partner], names[other], err)
+	}
+	quantities[unknown] = units.Quantity{
+		Value:   quantities[counterpart].Value * quantities[other].Value / partnerValue.Value,
+		Unit:    quantities[counterpart].Unit,
+		Figures: min(quantities[counterpart].Figures, quantities[other].Figures, partnerValue.Figures),
+	}
+	initial, _ := quantities[0].In(quantities[2].Unit)
+	if initial.Value < quantities[2].Value {
+		return nil, inputErrorf("C2 is above C1; a dilution only lowers the concentration")
+	}
+
+	format := func(quantity units.Quantity) string {
+		return units.Join(formatQuantity(precision, quantity.Value, quantity.Figures), quantity.Unit)
+	}
+	initialVolume, _ := quantities[1].In(quantities[3].Unit)
+	finalVolume := quantities[3]
+	places := min(decimalPlaces(initialVolume.Value, initialVolume.Figures), decimalPlaces(finalVolume.Value, finalVolume.Figures))
+	water := finalVolume.Value - initialVolume.Value
+	if math.Abs(water) < math.Pow(10, float64(-places))/2 {
+		water = 0
+	}
+	return &DilutionInfo{
+		Solved:        names[unknown],
+		Initial:       format(quantities[0]),
+		InitialVolume: format(quantities[1]),
+		Final:         format(quantities[2]),
+		FinalVolume:   format(quantities[3]),
+		Water:         format(units.Quantity{Value: water, Unit: finalVolume.Unit, Figures: significantPlaces(water, places)}),
+	}, nil
+}
+
+// describeDilution names what the variable at index of a dilution must be
+// for error messages.
+func describeDilution(index int) string {
+	if index%2 == 0 {
+		return "a concentration in M or N"
+	}
+	return "a volume"
+}
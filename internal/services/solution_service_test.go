@@ -0,0 +1,161 @@
+package services
+
+import (
+	"testing"
+)
+
+// solutionMeasures are the fields of a SolutionResponse the tests compare.
+type solutionMeasures struct {
+	Density, Molarity, Molality, MassFraction, Normality string
+}
+
+func TestSolution(t *testing.T) {
+	tests := []struct {
+		name          string
+		formula       string
+		concentration string
+		density       string
+		recordDensity bool
+		equivalents   int
+		volume        string
+		want          solutionMeasures
+		recipe        *SolutionRecipe
+		warnings      int
+	}{
+		{
+			name: "molarity to molality", formula: "NaCl", concentration: "0.100 M", density: "1.00 g/mL",
+			want: solutionMeasures{Density: "1.00 g/mL", Molarity: "0.100 M", Molality: "0.101 mol/kg",
+				MassFraction: "0.584 %", Normality: "0.100 N"},
+		},
+		{
+			name: "molality without the density", formula: "NaCl", concentration: "1.00 mol/kg",
+			want: solutionMeasures{Molality: "1.00 mol/kg", MassFraction: "5.52 %"},
+		},
+		{
+			name: "normality", formula: "H2SO4", concentration: "1.00 M", density: "1.06", equivalents: 2,
+			want: solutionMeasures{Density: "1.06 g/mL", Molarity: "1.00 M", Molality: "1.04 mol/kg",
+				MassFraction: "9.25 %", Normality: "2.00 N"},
+		},
+		{
+			name: "pure solute density only warns", formula: "H2SO4", concentration: "96 %",
+			want:     solutionMeasures{Molality: "240 mol/kg", MassFraction: "96 %"},
+			warnings: 1,
+		},
+		{
+			name: "record density on request", formula: "H2SO4", concentration: "96 %", recordDensity: true,
+			want:     solutionMeasures{Density: "1.8305 g/mL", Molarity: "18 M", Molality: "240 mol/kg", MassFraction: "96 %", Normality: "18 N"},
+			warnings: 1,
+		},
+		{
+			name: "recipe", formula: "NaCl", concentration: "0.100 M", volume: "250 mL",
+			want:   solutionMeasures{Molarity: "0.100 M", Normality: "0.100 N"},
+			recipe: &SolutionRecipe{Volume: "250 mL", Mass: "1.46 g"},
+		},
+	}
+
+	service := SolutionService{testService(t)}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := SolutionInput{
+				Formula:       test.formula,
+				Concentration: parseQuantity(t, test.concentration),
+				Density:       parseQuantity(t, test.density),
+				RecordDensity: test.recordDensity,
+				Equivalents:   test.equivalents,
+				Volume:        parseQuantity(t, test.volume),
+			}
+			response, err := service.GetResponse(input, Precision{})
+			if err != nil {
+				t.Fatalf("GetResponse: %v", err)
+			}
+			got := solutionMeasures{
+				Density:      response.Density,
+				Molarity:     response.Molarity,
+				Molality:     response.Molality,
+				MassFraction: response.MassFraction,
+				Normality:    response.Normality,
+			}
+			if got != test.want {
+				t.Errorf("GetResponse = %+v, want %+v", got, test.want)
+			}
+			if test.recipe != nil && (response.Recipe == nil || *response.Recipe != *test.recipe) {
+				t.Errorf("Recipe = %+v, want %+v", response.Recipe, test.recipe)
+			}
+			if len(response.Warnings) != test.warnings {
+				t.Errorf("Warnings = %q, want %d", response.Warnings, test.warnings)
+			}
+		})
+	}
+}
+
+func TestSolutionErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input SolutionInput
+	}{
+		{name: "nothing given"},
+		{name: "no concentration", input: SolutionInput{Formula: "NaCl"}},
+		{name: "no solute", input: SolutionInput{Concentration: parseQuantity(t, "1 M")}},
+		{name: "ion", input: SolutionInput{Formula: "Na+", Concentration: parseQuantity(t, "1 M")}},
+		{name: "mole fraction above 1", input: SolutionInput{Formula: "NaCl", Concentration: parseQuantity(t, "1.5")}},
+		{name: "no room for water", input: SolutionInput{Formula: "NaCl", Concentration: parseQuantity(t, "100 %")}},
+		{name: "volume without the molarity", input: SolutionInput{Formula: "NaCl", Concentration: parseQuantity(t, "5 %"), Volume: parseQuantity(t, "1 L")}},
+		{name: "not a concentration", input: SolutionInput{Formula: "NaCl", Concentration: parseQuantity(t, "5 g")}},
+	}
+
+	service := SolutionService{testService(t)}
+	for _, test := range tests {
+		if _, err := service.GetResponse(test.input, Precision{}); err == nil {
+			t.Errorf("%s: GetResponse succeeded, want an error", test.name)
+		}
+	}
+}
+
+func TestDilution(t *testing.T) {
+	tests := []struct {
+		name     string
+		dilution [4]string // C1, V1, C2 and V2
+		want     DilutionInfo
+		err      bool
+	}{
+		{
+			name: "final volume", dilution: [4]string{"2.00 M", "25.0 mL", "0.500 M", ""},
+			want: DilutionInfo{Solved: "V2", Initial: "2.00 M", InitialVolume: "25.0 mL", Final: "0.500 M", FinalVolume: "100 mL", Water: "75 mL"},
+		},
+		{
+			name: "stock volume in litres", dilution: [4]string{"12.0 M", "", "1.00 M", "1.00 L"},
+			want: DilutionInfo{Solved: "V1", Initial: "12.0 M", InitialVolume: "0.0833 L", Final: "1.00 M", FinalVolume: "1.00 L", Water: "0.92 L"},
+		},
+		{
+			name: "final concentration in mM", dilution: [4]string{"100 mM", "10.0 mL", "", "50.0 mL"},
+			want: DilutionInfo{Solved: "C2", Initial: "100 mM", InitialVolume: "10.0 mL", Final: "20.0 mM", FinalVolume: "50.0 mL", Water: "40.0 mL"},
+		},
+		{name: "concentration raised", dilution: [4]string{"1 M", "10 mL", "2 M", ""}, err: true},
+		{name: "two unknowns", dilution: [4]string{"1 M", "", "0.5 M", ""}, err: true},
+		{name: "volume as a concentration", dilution: [4]string{"1 M", "1 M", "0.5 M", ""}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dilution := Dilution{
+				Initial:       parseQuantity(t, test.dilution[0]),
+				InitialVolume: parseQuantity(t, test.dilution[1]),
+				Final:         parseQuantity(t, test.dilution[2]),
+				FinalVolume:   parseQuantity(t, test.dilution[3]),
+			}
+			info, err := solveDilution(dilution, Precision{})
+			if test.err {
+				if err == nil {
+					t.Fatalf("solveDilution succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("solveDilution: %v", err)
+			}
+			if *info != test.want {
+				t.Errorf("solveDilution = %+v, want %+v", *info, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"ChemistryPR/internal/units"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// StoichiometryRow is the amount of one species of a balanced reaction that
+// corresponds to the amount given for another.
+type StoichiometryRow struct {
+	Formula     string
+	Coefficient string // Coefficient in the balanced equation
+	Product     bool   // The species is formed rather than consumed
+	Given       bool   // The amount of the species was the one given
+	MolarMass   string // Molar mass, e.g. "18.015 g/mol"
+	Grams       string // Mass, e.g. "36.0 g"
+	Moles       string // Amount, e.g. "2.00 mol"
+	Volume      string // Volume as an ideal gas at BalanceOptions.Gas, e.g. "44.8 L"; empty unless the species is a gas
+}
+
+// reactionSpecies is a species of a balanced reaction with what it takes
+// to convert its amounts.
+type reactionSpecies struct {
+	formula      string
+	coefficient  int
+	product      bool
+	gas          bool     // Written with the state label "(g)"
+	molarMass    *big.Rat // Exact molar mass in g/mol
+	places       int      // Decimal places of molarMass
+	molarValue   float64
+	molarFigures int
+}
+
+// reactionSpecies parses every species of equation balanced with
+// coefficients, reagents first, and computes its molar mass as
+// MolarMassService does.
+func (service BalanceService) reactionSpecies(equation Equation, coefficients []int) ([]reactionSpecies, error) {
+	molarService := MolarMassService{service.ChemicalService}
+	species := make([]reactionSpecies, 0, len(coefficients))
+	for i, formula := range equation.Species() {
+		compound, err := service.ParseCompound(formula)
+		if err != nil {
+			return nil, err
+		}
+		weight, places, err := molarService.molarMass(compound)
+		if err != nil {
+			return nil, err
+		}
+		value, _ := weight.Float64()
+		species = append(species, reactionSpecies{
+			formula:      formula,
+			coefficient:  coefficients[i],
+			product:      i >= len(equation.Reagents),
+			gas:          compound.State == "g",
+			molarMass:    weight,
+			places:       places,
+			molarValue:   value,
+			molarFigures: significantPlaces(value, places),
+		})
+	}
+	return species, nil
+}
+
+// moles converts an amount of the species in g, mol or L of gas into moles
+// and the significant figures they are known to. A bare number is taken to
+// be in grams, and a volume to be that of an ideal gas of molarVolume.
+func (species reactionSpecies) moles(amount units.Quantity, molarVolume units.Quantity) (float64, int, error) {
+	if amount.Value < 0 {
+		return 0, 0, inputErrorf("amount of %s must not be negative, got %s", species.formula, amount)
+	}
+	amount = amount.Default(units.Gram)
+	var err error
+	switch amount.Unit.Dimension {
+	case units.Mass:
+		if species.molarValue == 0 {
+			return 0, 0, inputErrorf("%s has no mass to convert grams from", species.formula)
+		}
+		if amount, err = amount.In(units.Gram); err == nil {
+			return amount.Value / species.molarValue, min(amount.Figures, species.molarFigures), nil
+		}
+	case units.Amount:
+		if amount, err = amount.In(units.Mole); err == nil {
+			return amount.Value, amount.Figures, nil
+		}
+	case units.Volume:
+		if molarVolume.Value == 0 {
+			return 0, 0, inputErrorf("amount of %s is a volume; give the gas conditions to convert it to moles", species.formula)
+		}
+		if amount, err = amount.In(units.Litre); err == nil {
+			return amount.Value / molarVolume.Value, min(amount.Figures, molarVolume.Figures), nil
+		}
+	default:
+		err = inputErrorf("%w: %s is not a mass, an amount or a volume of gas", units.ErrDimension, amount)
+	}
+	return 0, 0, err
+}
+
+// grams writes moles of the species, known to figures, as a mass.
+func (species reactionSpecies) grams(precision Precision, moles float64, figures int) string {
+	return units.Join(formatQuantity(precision, moles*species.molarValue, min(figures, species.molarFigures)), units.Gram)
+}
+
+// stoichiometry converts options.Amount of the species options.Given into
+// the amounts of every species of equation balanced with coefficients,
+// reagents first. A mass goes through the molar mass of the given species
+// and a volume of gas through the molar volume at options.Gas; a bare
+// number is taken to be in grams.
+//
+// Volumes are written only for gases: the species labelled "(g)" and the
+// given species when its amount is a volume. The compound records do not
+// tell gases apart reliably, so unlabelled species get no volume.
+//
+// The given species may be written as in the equation or in any spelling
+// with the same canonical formula. Coefficients are exact, so every amount
+// keeps the significant figures of the given one, masses also those of
+// their molar mass and volumes those of the gas conditions.
+func (service BalanceService) stoichiometry(equation Equation, coefficients []int, molarVolume units.Quantity, options BalanceOptions) ([]StoichiometryRow, error) {
+	precision := options.Precision
+	if err := precision.Validate(); err != nil {
+		return nil, err
+	}
+	index, err := service.speciesIndex(equation.Species(), options.Given)
+	if err != nil {
+		return nil, err
+	}
+	species, err := service.reactionSpecies(equation, coefficients)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]StoichiometryRow, len(species))
+	for i, item := range species {
+		rows[i] = StoichiometryRow{
+			Formula:     item.formula,
+			Coefficient: fmt.Sprint(item.coefficient),
+			Product:     item.product,
+			Given:       i == index,
+			MolarMass:   units.Join(precision.FormatExact(item.molarMass, item.places), units.GramPerMole),
+		}
+	}
+	moles, figures, err := species[index].moles(options.Amount, molarVolume)
+	if err != nil {
+		return rows, err
+	}
+	for i, item := range species {
+		rowMoles := moles * float64(item.coefficient) / float64(species[index].coefficient)
+		rows[i].Grams = item.grams(precision, rowMoles, figures)
+		rows[i].Moles = units.Join(formatQuantity(precision, rowMoles, figures), units.Mole)
+		if item.gas || i == index && options.Amount.Unit.Dimension == units.Volume {
+			volumeFigures := min(figures, molarVolume.Figures)
+			rows[i].Volume = units.Join(formatQuantity(precision, rowMoles*molarVolume.Value, volumeFigures), units.Litre)
+		}
+	}
+	return rows, nil
+}
+
+// speciesIndex finds given among species, first as written and then by
+// canonical formula. An empty given selects the first species.
+func (service BalanceService) speciesIndex(species []string, given string) (int, error) {
+	given = strings.TrimSpace(given)
+	if given == "" {
+		return 0, nil
+	}
+	for i, formula := range species {
+		if formula == given {
+			return i, nil
+		}
+	}
+	canonical, err := service.Canonicalize(given)
+	if err != nil {
+		return 0, err
+	}
+	for i, formula := range species {
+		if other, err := service.Canonicalize(formula); err == nil && other == canonical {
+			return i, nil
+		}
+	}
+	return 0, inputErrorf("%s is not in the equation %s", given, strings.Join(species, ", "))
+}
+
+// parseAmounts reads amounts of species written as "H2=4 g, O2=32 g",
+// keyed by the index of the species among species. Entries are separated by
+// semicolons when there are any, so that amounts may use a decimal comma,
+// and by commas otherwise. The last "=" of an entry separates the amount.
+func (service BalanceService) parseAmounts(species []string, text string) (map[int]units.Quantity, error) {
+	amounts := make(map[int]units.Quantity)
+	separator := ","
+	if strings.Contains(text, ";") {
+		separator = ";"
+	}
+	for _, item := range strings.Split(text, separator) {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		equals := strings.LastIndex(item, "=")
+		if equals < 0 || strings.TrimSpace(item[:equals]) == "" {
+			return nil, inputErrorf("amount %q must be written as species=amount, e.g. \"O2=32 g\"", strings.TrimSpace(item))
+		}
+		index, err := service.speciesIndex(species, item[:equals])
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := amounts[index]; ok {
+			return nil, inputErrorf("amount of %s is given twice", species[index])
+		}
+		if amounts[index], err = units.Parse(item[equals+1:]); err != nil {
+			return nil, inputErrorf("amount of %s: %w", species[index], err)
+		}
+	}
+	return amounts, nil
+}
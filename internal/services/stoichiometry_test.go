@@ -0,0 +1,73 @@
+package services
+
+import (
+	"ChemistryPR/internal/units"
+	"testing"
+)
+
+func TestStoichiometry(t *testing.T) {
+	tests := []struct {
+		name     string
+		reaction string
+		given    string
+		amount   string
+		grams    []string
+		moles    []string
+		volumes  []string
+		err      bool
+	}{
+		{
+			name: "mass", reaction: "H2 + O2 = H2O", given: "H2", amount: "4.00 g",
+			grams:   []string{"4.00 g", "31.7 g", "35.7 g"},
+			moles:   []string{"1.98 mol", "0.992 mol", "1.98 mol"},
+			volumes: []string{"", "", ""},
+		},
+		{
+			name: "gases at STP", reaction: "CH4(g) + O2(g) = CO2(g) + H2O(l)", given: "O2(g)", amount: "2.00 mol",
+			grams:   []string{"16.0 g", "64.0 g", "44.0 g", "36.0 g"},
+			moles:   []string{"1.00 mol", "2.00 mol", "1.00 mol", "2.00 mol"},
+			volumes: []string{"22.4 L", "44.8 L", "22.4 L", ""},
+		},
+		{
+			name: "given as a volume", reaction: "N2 + H2 = NH3", given: "NH3", amount: "44.8 L", // 22.414 L/mol at STP
+			grams:   []string{"28.0 g", "6.04 g", "34.0 g"},
+			moles:   []string{"0.999 mol", "3.00 mol", "2.00 mol"},
+			volumes: []string{"", "", "44.8 L"},
+		},
+		{
+			name: "given by canonical formula", reaction: "C2H5OH + O2 = CO2 + H2O", given: "C2H6O", amount: "1.000 mol",
+			grams:   []string{"46.07 g", "95.99 g", "88.02 g", "54.05 g"},
+			moles:   []string{"1.000 mol", "3.000 mol", "2.000 mol", "3.000 mol"},
+			volumes: []string{"", "", "", ""},
+		},
+		{name: "species not in the equation", reaction: "H2 + O2 = H2O", given: "N2", amount: "1 mol", err: true},
+		{name: "negative amount", reaction: "H2 + O2 = H2O", given: "H2", amount: "-1 g", err: true},
+		{name: "not an amount", reaction: "H2 + O2 = H2O", given: "H2", amount: "1 atm", err: true},
+	}
+
+	service := BalanceService{ChemicalService: testService(t)}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			amount, err := units.Parse(test.amount)
+			if err != nil {
+				t.Fatalf("units.Parse(%q): %v", test.amount, err)
+			}
+			response, err := service.GetResponse(test.reaction, BalanceOptions{Given: test.given, Amount: amount})
+			if test.err {
+				if err == nil {
+					t.Fatalf("GetResponse(%q) succeeded, want an error", test.reaction)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetResponse(%q): %v", test.reaction, err)
+			}
+			for i, row := range response.Stoichiometry {
+				if row.Grams != test.grams[i] || row.Moles != test.moles[i] || row.Volume != test.volumes[i] {
+					t.Errorf("%s = %q, %q, %q; want %q, %q, %q", row.Formula, row.Grams, row.Moles, row.Volume,
+						test.grams[i], test.moles[i], test.volumes[i])
+				}
+			}
+		})
+	}
+}
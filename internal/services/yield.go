@@ -0,0 +1,146 @@
+package services
+
+import (
+	"ChemistryPR/internal/units"
+	"math"
+)
+
+// YieldResponse is the outcome of running a balanced reaction on the
+// available amounts of its reagents.
+type YieldResponse struct {
+	Limiting string             // Formula of the limiting reagent
+	Reagents []YieldReagentInfo // Reagents in the order of the equation
+	Products []YieldProductInfo // Products in the order of the equation
+}
+
+// YieldReagentInfo is what becomes of one reagent.
+type YieldReagentInfo struct {
+	Formula        string
+	Limiting       bool   // The reagent runs out first
+	Available      string // Mass available, empty when not given
+	AvailableMoles string // Amount available, empty when not given
+	Consumed       string // Mass used up by the reaction
+	ConsumedMoles  string // Amount used up by the reaction
+	Excess         string // Mass left over, zero for the limiting reagent; empty when not given
+	ExcessMoles    string // Amount left over; empty when not given
+}
+
+// YieldProductInfo is the yield of one product.
+type YieldProductInfo struct {
+	Formula          string
+	Theoretical      string // Mass formed when the limiting reagent is used up
+	TheoreticalMoles string // Amount formed when the limiting reagent is used up
+	Actual           string // Mass actually obtained, empty when not given
+	Percent          string // Actual yield over the theoretical one, in percent; empty when not given
+}
+
+// yield finds the limiting reagent of equation balanced with coefficients
+// from options.Available, the amounts of its reagents written as
+// parseAmounts reads them, and the excess of the other reagents and the
+// theoretical yield of every product. Reagents whose amount is not given
+// are taken to be in excess. The percent yield of a product is computed
+// when its actual yield is given in options.Actual, and is an error when
+// none of the product is formed.
+//
+// Amounts may be in g, mol or L of gas at molarVolume, bare numbers being
+// grams. The amounts used up and formed keep the significant figures of the
+// limiting reagent, and the excesses the decimal places of the amounts they
+// are the difference of.
+func (service BalanceService) yield(equation Equation, coefficients []int, molarVolume units.Quantity, options BalanceOptions) (*YieldResponse, error) {
+	precision := options.Precision
+	if err := precision.Validate(); err != nil {
+		return nil, err
+	}
+	formulas := equation.Species()
+	availableAmounts, err := service.parseAmounts(formulas, options.Available)
+	if err != nil {
+		return nil, err
+	}
+	actualAmounts, err := service.parseAmounts(formulas, options.Actual)
+	if err != nil {
+		return nil, err
+	}
+	species, err := service.reactionSpecies(equation, coefficients)
+	if err != nil {
+		return nil, err
+	}
+
+	moles := make([]float64, len(species))
+	figures := make([]int, len(species))
+	limiting := -1
+	for i, amount := range availableAmounts {
+		if species[i].product {
+			return nil, inputErrorf("%s is a product; give its actual yield instead of an available amount", species[i].formula)
+		}
+		if moles[i], figures[i], err = species[i].moles(amount, molarVolume); err != nil {
+			return nil, err
+		}
+		extent := moles[i] / float64(species[i].coefficient)
+		if limiting < 0 || extent < moles[limiting]/float64(species[limiting].coefficient) ||
+			extent == moles[limiting]/float64(species[limiting].coefficient) && i < limiting {
+			limiting = i
+		}
+	}
+	if limiting < 0 {
+		return nil, inputErrorf("no available amount of any reagent given")
+	}
+	for i := range actualAmounts {
+		if !species[i].product {
+			return nil, inputErrorf("%s is a reagent; give its available amount instead of an actual yield", species[i].formula)
+		}
+	}
+
+	extent := moles[limiting] / float64(species[limiting].coefficient)
+	extentFigures := figures[limiting]
+	formatMoles := func(value float64, figures int) string {
+		return units.Join(formatQuantity(precision, value, figures), units.Mole)
+	}
+	response := &YieldResponse{Limiting: species[limiting].formula}
+	for i, item := range species {
+		used := extent * float64(item.coefficient)
+		if !item.product {
+			info := YieldReagentInfo{
+				Formula:       item.formula,
+				Limiting:      i == limiting,
+				Consumed:      item.grams(precision, used, extentFigures),
+				ConsumedMoles: formatMoles(used, extentFigures),
+			}
+			if _, ok := availableAmounts[i]; ok {
+				// The excess is a difference of masses and is known to the
+				// decimal places of the less precise of them.
+				excess := moles[i] - used
+				places := min(decimalPlaces(moles[i]*item.molarValue, min(figures[i], item.molarFigures)),
+					decimalPlaces(used*item.molarValue, min(extentFigures, item.molarFigures)))
+				if i == limiting || math.Abs(excess*item.molarValue) < math.Pow(10, float64(-places))/2 {
+					excess = 0
+				}
+				excessFigures := significantPlaces(excess*item.molarValue, places)
+				info.Available = item.grams(precision, moles[i], figures[i])
+				info.AvailableMoles = formatMoles(moles[i], figures[i])
+				info.Excess = item.grams(precision, excess, excessFigures)
+				info.ExcessMoles = formatMoles(excess, excessFigures)
+			}
+			response.Reagents = append(response.Reagents, info)
+			continue
+		}
+
+		info := YieldProductInfo{
+			Formula:          item.formula,
+			Theoretical:      item.grams(precision, used, extentFigures),
+			TheoreticalMoles: formatMoles(used, extentFigures),
+		}
+		if amount, ok := actualAmounts[i]; ok {
+			obtained, obtainedFigures, err := item.moles(amount, molarVolume)
+			if err != nil {
+				return nil, err
+			}
+			if used == 0 {
+				return nil, inputErrorf("no %s is formed from the available amounts, so its percent yield is undefined", item.formula)
+			}
+			info.Actual = item.grams(precision, obtained, obtainedFigures)
+			info.Percent = precision.Format(obtained/used*100, min(obtainedFigures, extentFigures))
+		}
+		response.Products = append(response.Products, info)
+	}
+	return response, nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestYield(t *testing.T) {
+	tests := []struct {
+		name      string
+		reaction  string
+		available string
+		actual    string
+		limiting  string
+		excess    []string // Excess of every reagent
+		yields    []string // Theoretical yield of every product
+		percent   []string // Percent yield of every product
+		err       bool
+	}{
+		{
+			name: "hydrogen limiting", reaction: "H2 + O2 = H2O",
+			available: "H2=4.0 g, O2=64.0 g", actual: "H2O=30.0 g",
+			limiting: "H2", excess: []string{"0.0 g", "32 g"},
+			yields: []string{"36 g"}, percent: []string{"84"},
+		},
+		{
+			name: "oxygen limiting", reaction: "H2 + O2 = H2O",
+			available: "H2=4.00 g, O2=16.0 g",
+			limiting:  "O2", excess: []string{"1.98 g", "0.0 g"},
+			yields: []string{"18.0 g"}, percent: []string{""},
+		},
+		{
+			name: "reagent in excess when not given", reaction: "C3H8 + O2 = CO2 + H2O",
+			available: "C3H8=1.000 mol",
+			limiting:  "C3H8", excess: []string{"0.00 g", ""},
+			yields: []string{"132.0 g", "72.06 g"}, percent: []string{"", ""},
+		},
+		{name: "no product formed", reaction: "H2 + O2 = H2O", available: "H2=0 g, O2=16 g", actual: "H2O=1 g", err: true},
+		{name: "product given as available", reaction: "H2 + O2 = H2O", available: "H2O=1 g", err: true},
+		{name: "reagent given as actual", reaction: "H2 + O2 = H2O", available: "H2=1 g", actual: "O2=1 g", err: true},
+		{name: "negative amount", reaction: "H2 + O2 = H2O", available: "H2=-1 g", err: true},
+	}
+
+	service := BalanceService{ChemicalService: testService(t)}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := service.GetResponse(test.reaction, BalanceOptions{Available: test.available, Actual: test.actual})
+			if test.err {
+				if err == nil {
+					t.Fatalf("GetResponse(%q) succeeded, want an error", test.reaction)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetResponse(%q): %v", test.reaction, err)
+			}
+			yield := response.Yield
+			if yield.Limiting != test.limiting {
+				t.Errorf("Limiting = %q, want %q", yield.Limiting, test.limiting)
+			}
+			for i, reagent := range yield.Reagents {
+				if reagent.Excess != test.excess[i] {
+					t.Errorf("excess of %s = %q, want %q", reagent.Formula, reagent.Excess, test.excess[i])
+				}
+			}
+			for i, product := range yield.Products {
+				if product.Theoretical != test.yields[i] {
+					t.Errorf("theoretical yield of %s = %q, want %q", product.Formula, product.Theoretical, test.yields[i])
+				}
+				if product.Percent != test.percent[i] {
+					t.Errorf("percent yield of %s = %q, want %q", product.Formula, product.Percent, test.percent[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Package units parses and converts the physical quantities the
+// calculators take and return: masses, amounts of substance, volumes,
+// pressures, temperatures, energies, concentrations, densities and molar
+// masses.
+//
+// A quantity is written as a number followed by a unit, as in "25 mL",
+// "1.2 atm" or "-5 °C". It keeps the significant figures it was written
+// with, so that results derived from it can be rounded to match.
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Dimension is the kind of physical quantity a unit measures.
+type Dimension string
+
+const (
+	Dimensionless Dimension = ""              // Pure numbers and counts
+	Mass          Dimension = "mass"          // Base unit g
+	Amount        Dimension = "amount"        // Base unit mol
+	Volume        Dimension = "volume"        // Base unit L
+	Pressure      Dimension = "pressure"      // Base unit kPa
+	Temperature   Dimension = "temperature"   // Base unit K
+	Energy        Dimension = "energy"        // Base unit J
+	Concentration Dimension = "concentration" // Base unit M, moles per litre
+	Molality      Dimension = "molality"      // Base unit mol/kg, moles per kilogram of solvent
+	Normality     Dimension = "normality"     // Base unit N, equivalents per litre
+	Fraction      Dimension = "fraction"      // Base unit %, parts per hundred
+	Density       Dimension = "density"       // Base unit g/mL
+	MolarMass     Dimension = "molar mass"    // Base unit g/mol
+	MolarVolume   Dimension = "molar volume"  // Base unit L/mol
+)
+
+// Unit is a unit of one dimension. A value v in the unit is v*factor+offset
+// in the base unit of the dimension.
+type Unit struct {
+	Symbol    string
+	Dimension Dimension
+	factor    float64
+	offset    float64
+}
+
+var (
+	None = Unit{} // The unit of bare numbers
+
+	Gram      = Unit{Symbol: "g", Dimension: Mass, factor: 1}
+	Milligram = Unit{Symbol: "mg", Dimension: Mass, factor: 1e-3}
+	Kilogram  = Unit{Symbol: "kg", Dimension: Mass, factor: 1e3}
+
+	Mole      = Unit{Symbol: "mol", Dimension: Amount, factor: 1}
+	Millimole = Unit{Symbol: "mmol", Dimension: Amount, factor: 1e-3}
+
+	Litre      = Unit{Symbol: "L", Dimension: Volume, factor: 1}
+	Millilitre = Unit{Symbol: "mL", Dimension: Volume, factor: 1e-3}
+
+	Kilopascal            = Unit{Symbol: "kPa", Dimension: Pressure, factor: 1}
+	Pascal                = Unit{Symbol: "Pa", Dimension: Pressure, factor: 1e-3}
+	Atmosphere            = Unit{Symbol: "atm", Dimension: Pressure, factor: 101.325}
+	MillimetreOfMercury   = Unit{Symbol: "mmHg", Dimension: Pressure, factor: 0.133322387415}
+	Torr                  = Unit{Symbol: "Torr", Dimension: Pressure, factor: 101.325 / 760}
+	Bar                   = Unit{Symbol: "bar", Dimension: Pressure, factor: 100}
+	Kelvin                = Unit{Symbol: "K", Dimension: Temperature, factor: 1}
+	Celsius               = Unit{Symbol: "°C", Dimension: Temperature, factor: 1, offset: 273.15}
+	Joule                 = Unit{Symbol: "J", Dimension: Energy, factor: 1}
+	Kilojoule             = Unit{Symbol: "kJ", Dimension: Energy, factor: 1e3}
+	Calorie               = Unit{Symbol: "cal", Dimension: Energy, factor: 4.184}
+	Kilocalorie           = Unit{Symbol: "kcal", Dimension: Energy, factor: 4184}
+	Molar                 = Unit{Symbol: "M", Dimension: Concentration, factor: 1}
+	Millimolar            = Unit{Symbol: "mM", Dimension: Concentration, factor: 1e-3}
+	Molal                 = Unit{Symbol: "mol/kg", Dimension: Molality, factor: 1}
+	Millimolal            = Unit{Symbol: "mmol/kg", Dimension: Molality, factor: 1e-3}
+	Normal                = Unit{Symbol: "N", Dimension: Normality, factor: 1}
+	Millinormal           = Unit{Symbol: "mN", Dimension: Normality, factor: 1e-3}
+	Percent               = Unit{Symbol: "%", Dimension: Fraction, factor: 1}
+	PartsPerMillion       = Unit{Symbol: "ppm", Dimension: Fraction, factor: 1e-4}
+	GramPerMillilitre     = Unit{Symbol: "g/mL", Dimension: Density, factor: 1}
+	GramPerLitre          = Unit{Symbol: "g/L", Dimension: Density, factor: 1e-3}
+	KilogramPerCubicMetre = Unit{Symbol: "kg/m3", Dimension: Density, factor: 1e-3}
+	GramPerMole           = Unit{Symbol: "g/mol", Dimension: MolarMass, factor: 1}
+	KilogramPerMole       = Unit{Symbol: "kg/mol", Dimension: MolarMass, factor: 1e3}
+	LitrePerMole          = Unit{Symbol: "L/mol", Dimension: MolarVolume, factor: 1}
+)
+
+// bySymbol maps every accepted spelling of a unit to the unit.
+var bySymbol = map[string]Unit{
+	"g": Gram, "mg": Milligram, "kg": Kilogram,
+	"mol": Mole, "mmol": Millimole,
+	"L": Litre, "l": Litre, "mL": Millilitre, "ml": Millilitre,
+	"kPa": Kilopascal, "Pa": Pascal, "atm": Atmosphere, "mmHg": MillimetreOfMercury,
+	"Torr": Torr, "torr": Torr, "bar": Bar,
+	"K": Kelvin, "°C": Celsius, "℃": Celsius, "C": Celsius, "degC": Celsius,
+	"J": Joule, "kJ": Kilojoule, "cal": Calorie, "kcal": Kilocalorie,
+	"M": Molar, "mol/L": Molar, "mol/l": Molar, "mM": Millimolar, "mmol/L": Millimolar, "mmol/l": Millimolar,
+	"mol/kg": Molal, "m": Molal, "mmol/kg": Millimolal,
+	"N": Normal, "eq/L": Normal, "eq/l": Normal, "mN": Millinormal,
+	"%": Percent, "ppm": PartsPerMillion,
+	"g/mL": GramPerMillilitre, "g/ml": GramPerMillilitre, "g/cm3": GramPerMillilitre, "g/cm³": GramPerMillilitre,
+	"g/L": GramPerLitre, "g/l": GramPerLitre, "kg/m3": KilogramPerCubicMetre, "kg/m³": KilogramPerCubicMetre,
+	"g/mol": GramPerMole, "kg/mol": KilogramPerMole,
+	"L/mol": LitrePerMole, "l/mol": LitrePerMole,
+}
+
+// Lookup returns the unit written as symbol.
+func Lookup(symbol string) (Unit, bool) {
+	unit, ok := bySymbol[symbol]
+	return unit, ok
+}
+
+// ErrUnknownUnit is returned for a quantity written with a unit that is
+// not known.
+var ErrUnknownUnit = errors.New("unknown unit")
+
+// ErrDimension is returned when a quantity is converted to, or read as, a
+// unit of another dimension, such as "25 mL" read as a mass.
+var ErrDimension = errors.New("wrong dimension")
+
+// Quantity is a value in a unit together with the significant figures it
+// is known to.
+type Quantity struct {
+	Value   float64
+	Unit    Unit
+	Figures int // Significant figures of the value, e.g. 2 for "25 mL"
+}
+
+// quantityPattern splits a quantity into its number and its unit.
+var quantityPattern = regexp.MustCompile(`^([-+]?(?:\d+(?:[.,]\d*)?|[.,]\d+)(?:[eE][-+]?\d+)?)\s*(.*)$`)
+
+// Parse reads a quantity such as "25 mL", "1,2 atm" or "6.02e23". The unit
+// is optional and a bare number is Dimensionless. Leading zeros are not
+// significant; trailing zeros are, even without a decimal point, so
+// "180 g" has three significant figures.
+func Parse(text string) (Quantity, error) {
+	text = strings.TrimSpace(text)
+	match := quantityPattern.FindStringSubmatch(text)
+	if match == nil {
+		return Quantity{}, fmt.Errorf("%q is not a number with an optional unit", text)
+	}
+	number := strings.Replace(match[1], ",", ".", 1)
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || math.IsInf(value, 0) {
+		return Quantity{}, fmt.Errorf("%q is not a number", match[1])
+	}
+	unit, ok := None, true
+	if symbol := strings.TrimSpace(match[2]); symbol != "" {
+		if unit, ok = Lookup(symbol); !ok {
+			return Quantity{}, fmt.Errorf("%w %q in %q", ErrUnknownUnit, symbol, text)
+		}
+	}
+	return Quantity{Value: value, Unit: unit, Figures: SignificantFigures(number)}, nil
+}
+
+// ParseIn reads a quantity as Parse does and converts it to unit. A bare
+// number is taken to be in unit already.
+func ParseIn(text string, unit Unit) (Quantity, error) {
+	quantity, err := Parse(text)
+	if err != nil {
+		return Quantity{}, err
+	}
+	return quantity.Default(unit).In(unit)
+}
+
+// Default returns the quantity in unit when it is a bare number, and the
+// quantity unchanged otherwise.
+func (quantity Quantity) Default(unit Unit) Quantity {
+	if quantity.Unit == None {
+		quantity.Unit = unit
+	}
+	return quantity
+}
+
+// In converts the quantity to unit, keeping its significant figures. A
+// change of offset, as from °C to K, keeps its decimal places instead, so
+// that "25 °C" becomes 298 K rather than 300 K.
+func (quantity Quantity) In(unit Unit) (Quantity, error) {
+	if quantity.Unit.Dimension != unit.Dimension {
+		return Quantity{}, fmt.Errorf("%w: %s is not a %s", ErrDimension, quantity, describe(unit.Dimension))
+	}
+	if quantity.Unit == unit {
+		return quantity, nil
+	}
+	base := quantity.Value*quantity.Unit.factor + quantity.Unit.offset
+	converted := Quantity{Value: (base - unit.offset) / unit.factor, Unit: unit, Figures: quantity.Figures}
+	if quantity.Unit.offset != unit.offset && quantity.Figures > 0 {
+		places := quantity.Figures - 1 - magnitude(quantity.Value)
+		converted.Figures = max(places+1+magnitude(converted.Value), 1)
+	}
+	return converted, nil
+}
+
+// magnitude returns the power of ten of the leading digit of value, zero
+// for zero.
+func magnitude(value float64) int {
+	if value == 0 {
+		return 0
+	}
+	return int(math.Floor(math.Log10(math.Abs(value))))
+}
+
+// String writes the quantity with all the digits of its value, as
+// "25 mL".
+func (quantity Quantity) String() string {
+	return Join(strconv.FormatFloat(quantity.Value, 'g', -1, 64), quantity.Unit)
+}
+
+// Join writes a number already formatted as text followed by its unit, as
+// "25 mL"; a Dimensionless number is written alone.
+func Join(text string, unit Unit) string {
+	if unit.Symbol == "" {
+		return text
+	}
+	return text + " " + unit.Symbol
+}
+
+// SignificantFigures counts the significant digits of a number written in
+// decimal, ignoring its sign and any exponent. Zero has one.
+func SignificantFigures(text string) int {
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		text = text[:i]
+	}
+	digits := strings.TrimLeft(strings.NewReplacer(".", "", ",", "", "+", "", "-", "").Replace(text), "0")
+	return max(len(digits), 1)
+}
+
+// describe names a dimension for error messages.
+func describe(dimension Dimension) string {
+	if dimension == Dimensionless {
+		return "pure number"
+	}
+	return string(dimension)
+}
@@ -0,0 +1,148 @@
+package units
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text    string
+		value   float64
+		unit    Unit
+		figures int
+	}{
+		{text: "25 mL", value: 25, unit: Millilitre, figures: 2},
+		{text: "25mL", value: 25, unit: Millilitre, figures: 2},
+		{text: "1,2 atm", value: 1.2, unit: Atmosphere, figures: 2},
+		{text: "6.02e23", value: 6.02e23, unit: None, figures: 3},
+		{text: "180 g", value: 180, unit: Gram, figures: 3},
+		{text: "0.00250 mol", value: 0.0025, unit: Mole, figures: 3},
+		{text: "-5 °C", value: -5, unit: Celsius, figures: 1},
+		{text: "0", value: 0, unit: None, figures: 1},
+		{text: ".5 M", value: 0.5, unit: Molar, figures: 1},
+		{text: "1.0 g/cm3", value: 1, unit: GramPerMillilitre, figures: 2},
+		// Single letters that could stand for other units are read as the
+		// one used in solution and gas calculations.
+		{text: "25 C", value: 25, unit: Celsius, figures: 2},
+		{text: "0.5 m", value: 0.5, unit: Molal, figures: 1},
+	}
+
+	for _, test := range tests {
+		quantity, err := Parse(test.text)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", test.text, err)
+			continue
+		}
+		if quantity.Value != test.value || quantity.Unit != test.unit || quantity.Figures != test.figures {
+			t.Errorf("Parse(%q) = %v %q %d figures, want %v %q %d figures", test.text,
+				quantity.Value, quantity.Unit.Symbol, quantity.Figures, test.value, test.unit.Symbol, test.figures)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		err  error // nil for text that is not a number
+	}{
+		{text: "25 furlongs", err: ErrUnknownUnit},
+		{text: "1 MOL", err: ErrUnknownUnit},
+		{text: "3 cm", err: ErrUnknownUnit},
+		{text: "g"},
+		{text: ""},
+		{text: "1e999"},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.text)
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", test.text)
+			continue
+		}
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("Parse(%q) error = %v, want %v", test.text, err, test.err)
+		}
+	}
+}
+
+func TestSignificantFigures(t *testing.T) {
+	tests := []struct {
+		text    string
+		figures int
+	}{
+		{text: "25", figures: 2},
+		{text: "0.025", figures: 2},
+		{text: "0.0250", figures: 3},
+		{text: "180", figures: 3},
+		{text: "1.00e-3", figures: 3},
+		{text: "-4.5", figures: 2},
+		{text: "1,50", figures: 3},
+		{text: "0.0", figures: 1},
+	}
+
+	for _, test := range tests {
+		if figures := SignificantFigures(test.text); figures != test.figures {
+			t.Errorf("SignificantFigures(%q) = %d, want %d", test.text, figures, test.figures)
+		}
+	}
+}
+
+func TestIn(t *testing.T) {
+	tests := []struct {
+		text    string
+		unit    Unit
+		value   float64
+		figures int
+		err     error
+	}{
+		{text: "250 mL", unit: Litre, value: 0.25, figures: 3},
+		{text: "1.00 atm", unit: Kilopascal, value: 101.325, figures: 3},
+		{text: "760 Torr", unit: Atmosphere, value: 1, figures: 3},
+		{text: "760 mmHg", unit: Kilopascal, value: 101.3250144354, figures: 3},
+		{text: "1 kcal", unit: Joule, value: 4184, figures: 1},
+		{text: "25 mmol/L", unit: Molar, value: 0.025, figures: 2},
+		{text: "50 ppm", unit: Percent, value: 0.005, figures: 2},
+		{text: "1000 kg/m3", unit: GramPerMillilitre, value: 1, figures: 4},
+		// A change of offset keeps the decimal places.
+		{text: "25 °C", unit: Kelvin, value: 298.15, figures: 3},
+		{text: "25.0 C", unit: Kelvin, value: 298.15, figures: 4},
+		{text: "300 K", unit: Celsius, value: 26.85, figures: 2},
+		{text: "25 mL", unit: Gram, err: ErrDimension},
+		{text: "1 m", unit: Molar, err: ErrDimension},
+		{text: "5", unit: Gram, err: ErrDimension},
+	}
+
+	for _, test := range tests {
+		quantity, err := Parse(test.text)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", test.text, err)
+		}
+		converted, err := quantity.In(test.unit)
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("%q in %s error = %v, want %v", test.text, test.unit.Symbol, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q in %s: %v", test.text, test.unit.Symbol, err)
+			continue
+		}
+		if math.Abs(converted.Value-test.value) > 1e-9*math.Abs(test.value) || converted.Figures != test.figures {
+			t.Errorf("%q in %s = %v with %d figures, want %v with %d figures", test.text, test.unit.Symbol,
+				converted.Value, converted.Figures, test.value, test.figures)
+		}
+	}
+}
+
+func TestParseIn(t *testing.T) {
+	quantity, err := ParseIn("5", Gram)
+	if err != nil || quantity.Value != 5 || quantity.Unit != Gram {
+		t.Errorf(`ParseIn("5", Gram) = %v, %v; want 5 g`, quantity, err)
+	}
+	if _, err := ParseIn("5 mL", Gram); !errors.Is(err, ErrDimension) {
+		t.Errorf(`ParseIn("5 mL", Gram) error = %v, want %v`, err, ErrDimension)
+	}
+}